@@ -2,7 +2,9 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"hummus-lang/token"
+	"regexp"
 	"strings"
 )
 
@@ -101,9 +103,10 @@ func (rs *ReturnStatement) String() string {
 }
 
 type LetStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token
+	Name     *Identifier
+	Value    Expression
+	Constant bool
 }
 
 func (ls *LetStatement) statementNode()       {}
@@ -125,6 +128,51 @@ func (ls *LetStatement) String() string {
 	return out.String()
 }
 
+// EnumStatement declares a tagged union such as
+// `enum Shape { Circle(r), Rect(w, h), Empty }`
+type EnumStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Variants []*EnumVariant
+}
+
+type EnumVariant struct {
+	Name   *Identifier
+	Fields []*Identifier
+}
+
+func (es *EnumStatement) statementNode()       {}
+func (es *EnumStatement) TokenLiteral() string { return es.Token.Literal }
+func (es *EnumStatement) String() string {
+	var out bytes.Buffer
+
+	variants := []string{}
+	for _, v := range es.Variants {
+		variants = append(variants, v.String())
+	}
+
+	out.WriteString(es.TokenLiteral() + " ")
+	out.WriteString(es.Name.String())
+	out.WriteString(" { ")
+	out.WriteString(strings.Join(variants, ", "))
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+func (ev *EnumVariant) String() string {
+	if len(ev.Fields) == 0 {
+		return ev.Name.String()
+	}
+
+	fields := []string{}
+	for _, f := range ev.Fields {
+		fields = append(fields, f.String())
+	}
+
+	return ev.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -170,6 +218,7 @@ func (ie *IfExpression) String() string {
 type FunctionLiteral struct {
 	Token      token.Token
 	Parameters []*Identifier
+	ReturnType *TypeAnnotation
 	Body       *BlockStatement
 }
 
@@ -187,6 +236,9 @@ func (fl *FunctionLiteral) String() string {
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
 	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
 	out.WriteString(fl.Body.String())
 
 	return out.String()
@@ -234,6 +286,15 @@ func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 type StringLiteral struct {
 	Token token.Token
 	Value string
@@ -250,6 +311,34 @@ func (sl *StringLiteral) String() string {
 	return out.String()
 }
 
+// RegexLiteral is a regex written as /pattern/flags, compiled when it is parsed
+type RegexLiteral struct {
+	Token   token.Token
+	Pattern string
+	Flags   string
+	Regexp  *regexp.Regexp
+}
+
+func (rl *RegexLiteral) expressionNode()      {}
+func (rl *RegexLiteral) TokenLiteral() string { return rl.Token.Literal }
+func (rl *RegexLiteral) String() string       { return rl.Token.Literal }
+
+// CompileRegex compiles a pattern with flags made up of i (ignore case), m
+// (^ and $ match at line breaks) and s (. matches line breaks)
+func CompileRegex(pattern string, flags string) (*regexp.Regexp, error) {
+	for _, f := range flags {
+		if !strings.ContainsRune("ims", f) {
+			return nil, fmt.Errorf("unknown regex flag %q", f)
+		}
+	}
+
+	if flags != "" {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	return regexp.Compile(pattern)
+}
+
 type ArrayLiteral struct {
 	Token token.Token
 	Elements []Expression
@@ -272,10 +361,106 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+type SetLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()      {}
+func (sl *SetLiteral) TokenLiteral() string { return sl.Token.Literal }
+func (sl *SetLiteral) String() string {
+	elements := []string{}
+	for _, e := range sl.Elements {
+		elements = append(elements, e.String())
+	}
+
+	return "#{" + strings.Join(elements, ", ") + "}"
+}
+
+// HashLiteral keeps its pairs in source order, which is the order the hash
+// iterates in
+type HashLiteral struct {
+	Token  token.Token
+	Keys   []Expression
+	Values []Expression
+}
+
+func (hl *HashLiteral) expressionNode()      {}
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+func (hl *HashLiteral) String() string {
+	pairs := []string{}
+	for i, key := range hl.Keys {
+		pairs = append(pairs, key.String()+": "+hl.Values[i].String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+type TupleLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode()      {}
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+func (tl *TupleLiteral) String() string {
+	elements := []string{}
+	for _, e := range tl.Elements {
+		elements = append(elements, e.String())
+	}
+
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// Comprehension builds an array, or a set when Token is `#{`, such as
+// `[x * 2 for x in xs if x > 0]`. Condition is nil when there is no `if`. Key
+// is only set for hash comprehensions, `{k: v for x in xs}`, where Element is
+// the value
+type Comprehension struct {
+	Token     token.Token
+	Key       Expression
+	Element   Expression
+	Variable  *Identifier
+	Iterable  Expression
+	Condition Expression
+}
+
+func (c *Comprehension) expressionNode()      {}
+func (c *Comprehension) TokenLiteral() string { return c.Token.Literal }
+func (c *Comprehension) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(c.TokenLiteral())
+	if c.Key != nil {
+		out.WriteString(c.Key.String())
+		out.WriteString(": ")
+	}
+	out.WriteString(c.Element.String())
+	out.WriteString(" for ")
+	out.WriteString(c.Variable.String())
+	out.WriteString(" in ")
+	out.WriteString(c.Iterable.String())
+	if c.Condition != nil {
+		out.WriteString(" if ")
+		out.WriteString(c.Condition.String())
+	}
+	if c.Token.Type == token.SetBrace || c.Key != nil {
+		out.WriteString("}")
+	} else {
+		out.WriteString("]")
+	}
+
+	return out.String()
+}
+
 type IndexExpression struct {
 	Token token.Token
 	Left Expression
 	Right Expression
+	Optional bool
 }
 
 func (ie *IndexExpression) expressionNode()      {}
@@ -285,21 +470,90 @@ func (ie *IndexExpression) String() string       {
 
 	out.WriteString("[")
 	out.WriteString(ie.Left.String())
-	out.WriteString("][")
+	if ie.Optional {
+		out.WriteString("]?[")
+	} else {
+		out.WriteString("][")
+	}
 	out.WriteString(ie.Right.String())
 	out.WriteString("]")
 
 	return out.String()
 }
 
+// MemberExpression is `left.Property`, or `left?.Property` when Optional
+type MemberExpression struct {
+	Token    token.Token
+	Left     Expression
+	Property *Identifier
+	Optional bool
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(me.Left.String())
+	if me.Optional {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
+	out.WriteString(me.Property.String())
+
+	return out.String()
+}
+
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (nl *NullLiteral) expressionNode()      {}
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+func (nl *NullLiteral) String() string       { return nl.Token.Literal }
+
 type Identifier struct {
 	Token token.Token
 	Value string
+	Type  *TypeAnnotation
 }
 
 func (i *Identifier) expressionNode()      {}
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
-func (i *Identifier) String() string       { return i.Value }
+func (i *Identifier) String() string {
+	if i.Type != nil {
+		return i.Value + ": " + i.Type.String()
+	}
+	return i.Value
+}
+
+// TypeAnnotation is an optional type written after a declared name or a
+// function's parameter list. Name is "[]" for array types and "fn" for
+// function types
+type TypeAnnotation struct {
+	Token      token.Token
+	Name       string
+	Element    *TypeAnnotation
+	Parameters []*TypeAnnotation
+	Return     *TypeAnnotation
+}
+
+func (ta *TypeAnnotation) TokenLiteral() string { return ta.Token.Literal }
+func (ta *TypeAnnotation) String() string {
+	switch ta.Name {
+	case "[]":
+		return "[" + ta.Element.String() + "]"
+	case "fn":
+		params := []string{}
+		for _, p := range ta.Parameters {
+			params = append(params, p.String())
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " + ta.Return.String()
+	default:
+		return ta.Name
+	}
+}
 
 type Program struct {
 	Statements []Statement
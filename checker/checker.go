@@ -0,0 +1,436 @@
+// Package checker finds type errors in a program without running it.
+//
+// Checking is gradual: names and parameters without annotations are treated
+// as `any` unless their type is obvious from a literal, so unannotated code
+// keeps behaving dynamically.
+package checker
+
+import (
+	"fmt"
+	"hummus-lang/ast"
+	"hummus-lang/token"
+)
+
+var predefs = map[string]Type{
+	"len":       &FunctionType{Parameters: []Type{Any}, Return: Int},
+	"print":     &FunctionType{Return: Null},
+	"printLine": &FunctionType{Return: Null},
+}
+
+type scope struct {
+	types map[string]Type
+	outer *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{types: make(map[string]Type), outer: outer}
+}
+
+func (s *scope) get(name string) (Type, bool) {
+	t, ok := s.types[name]
+	if !ok && s.outer != nil {
+		t, ok = s.outer.get(name)
+	}
+	return t, ok
+}
+
+type checker struct {
+	errors []string
+
+	// declared return types of the functions currently being checked
+	returns []Type
+}
+
+// Check reports every type error found in program, with line numbers
+func Check(program *ast.Program) []string {
+	c := &checker{errors: []string{}}
+
+	s := newScope(nil)
+	for _, stmt := range program.Statements {
+		c.checkStatement(stmt, s)
+	}
+
+	return c.errors
+}
+
+func (c *checker) addError(line int, format string, a ...interface{}) {
+	c.errors = append(c.errors, fmt.Sprintf("line %d: %s", line, fmt.Sprintf(format, a...)))
+}
+
+func (c *checker) checkStatement(stmt ast.Statement, s *scope) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		return c.checkLetStatement(stmt, s)
+
+	case *ast.ReturnStatement:
+		t := c.checkExpression(stmt.ReturnValue, s)
+		if len(c.returns) > 0 {
+			expected := c.returns[len(c.returns)-1]
+			if !assignable(t, expected) {
+				c.addError(stmt.Token.Line, "can not return %s from a function returning %s", t, expected)
+			}
+		}
+		return t
+
+	case *ast.ExpressionStatement:
+		return c.checkExpression(stmt.Expression, s)
+
+	default:
+		return Any
+	}
+}
+
+func (c *checker) checkLetStatement(stmt *ast.LetStatement, s *scope) Type {
+	declared := fromAnnotation(stmt.Name.Type)
+
+	// bind functions before checking their body so they can call themselves
+	if fn, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		if stmt.Name.Type == nil {
+			s.types[stmt.Name.Value] = functionSignature(fn)
+		} else {
+			s.types[stmt.Name.Value] = declared
+		}
+	}
+
+	t := c.checkExpression(stmt.Value, s)
+	if !assignable(t, declared) {
+		c.addError(stmt.Token.Line, "can not assign %s to `%s` of type %s", t, stmt.Name.Value, declared)
+	}
+
+	if stmt.Name.Type == nil {
+		s.types[stmt.Name.Value] = t
+	} else {
+		s.types[stmt.Name.Value] = declared
+	}
+
+	return Null
+}
+
+func (c *checker) checkBlock(block *ast.BlockStatement, s *scope) Type {
+	var result Type = Null
+
+	for _, stmt := range block.Statements {
+		result = c.checkStatement(stmt, s)
+	}
+
+	return result
+}
+
+// checkBranch checks a block that may or may not run. Blocks share their
+// environment with the enclosing function at runtime, so anything bound
+// inside one is only known to be of a single type if it was already that type
+func (c *checker) checkBranch(block *ast.BlockStatement, s *scope) Type {
+	inner := newScope(s)
+	result := c.checkBlock(block, inner)
+
+	for name, t := range inner.types {
+		if previous, ok := s.get(name); ok {
+			s.types[name] = join(previous, t)
+		} else {
+			s.types[name] = Any
+		}
+	}
+
+	return result
+}
+
+func (c *checker) checkExpression(exp ast.Expression, s *scope) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+
+	case *ast.FloatLiteral:
+		return Float
+
+	case *ast.StringLiteral:
+		return String
+
+	case *ast.Boolean:
+		return Bool
+
+	case *ast.NullLiteral:
+		return Null
+
+	case *ast.Identifier:
+		if t, ok := s.get(exp.Value); ok {
+			return t
+		}
+		if t, ok := predefs[exp.Value]; ok {
+			return t
+		}
+		return Any
+
+	case *ast.PrefixExpression:
+		return c.checkPrefixExpression(exp, s)
+
+	case *ast.InfixExpression:
+		return c.checkInfixExpression(exp, s)
+
+	case *ast.IfExpression:
+		c.checkExpression(exp.Condition, s)
+
+		consequence := c.checkBranch(exp.Consequence, s)
+		if exp.Alternative == nil {
+			return Any
+		}
+		alternative := c.checkBranch(exp.Alternative, s)
+
+		return join(consequence, alternative)
+
+	case *ast.FunctionLiteral:
+		return c.checkFunctionLiteral(exp, s)
+
+	case *ast.CallExpression:
+		return c.checkCallExpression(exp, s)
+
+	case *ast.ArrayLiteral:
+		var element Type
+		for _, e := range exp.Elements {
+			t := c.checkExpression(e, s)
+			if element == nil {
+				element = t
+			} else {
+				element = join(element, t)
+			}
+		}
+		if element == nil {
+			element = Any
+		}
+		return &ArrayType{Element: element}
+
+	case *ast.HashLiteral:
+		for i, key := range exp.Keys {
+			c.checkExpression(key, s)
+			c.checkExpression(exp.Values[i], s)
+		}
+		return Any
+
+	case *ast.SetLiteral:
+		for _, e := range exp.Elements {
+			c.checkExpression(e, s)
+		}
+		return Any
+
+	case *ast.TupleLiteral:
+		for _, e := range exp.Elements {
+			c.checkExpression(e, s)
+		}
+		return Any
+
+	case *ast.Comprehension:
+		return c.checkComprehension(exp, s)
+
+	case *ast.MemberExpression:
+		c.checkExpression(exp.Left, s)
+		return Any
+
+	case *ast.IndexExpression:
+		left := c.checkExpression(exp.Left, s)
+		index := c.checkExpression(exp.Right, s)
+
+		switch left := left.(type) {
+		case *ArrayType:
+			if !assignable(index, Int) {
+				c.addError(exp.Token.Line, "can not index %s with %s", left, index)
+			}
+			return left.Element
+		default:
+			if left == String {
+				if !assignable(index, Int) {
+					c.addError(exp.Token.Line, "can not index %s with %s", left, index)
+				}
+				return String
+			}
+			return Any
+		}
+
+	default:
+		return Any
+	}
+}
+
+func (c *checker) checkPrefixExpression(exp *ast.PrefixExpression, s *scope) Type {
+	right := c.checkExpression(exp.Right, s)
+
+	switch exp.Operator {
+	case "!":
+		return Bool
+	case "-":
+		if right == Any {
+			return Any
+		}
+		if right == Float {
+			return Float
+		}
+		if right != Int {
+			c.addError(exp.Token.Line, "unknown operator: unary %s not defined for %s", exp.Operator, right)
+		}
+		return Int
+	case "~":
+		if right == Any {
+			return Any
+		}
+		if right != Int {
+			c.addError(exp.Token.Line, "unknown operator: unary %s not defined for %s", exp.Operator, right)
+		}
+		return Int
+	default:
+		return Any
+	}
+}
+
+func (c *checker) checkInfixExpression(exp *ast.InfixExpression, s *scope) Type {
+	left := c.checkExpression(exp.Left, s)
+	right := c.checkExpression(exp.Right, s)
+
+	var result Type
+	var allowed []Type
+
+	switch exp.Operator {
+	case "==", "!=":
+		return Bool
+	case "+":
+		allowed = []Type{Int, Float, String}
+		result = left
+	case "-", "*", "/", "%":
+		allowed = []Type{Int, Float}
+		result = left
+	case "&", "|", "^", "<<", ">>":
+		allowed = []Type{Int}
+		result = Int
+	case "<", ">":
+		allowed = []Type{Int, Float}
+		result = Bool
+	default:
+		return Any
+	}
+
+	if left == Any || right == Any {
+		if result == Bool {
+			return Bool
+		}
+		return Any
+	}
+
+	// an integer mixed with a float is treated as a float
+	mixed := (left == Int && right == Float) || (left == Float && right == Int)
+	if mixed && contains(allowed, Float) {
+		left, right = Float, Float
+		if result != Bool {
+			result = Float
+		}
+	}
+
+	if left.String() != right.String() {
+		c.addError(exp.Token.Line, "type mismatch: can not %s %s and %s", exp.Operator, left, right)
+		return result
+	}
+
+	if contains(allowed, left) {
+		return result
+	}
+
+	c.addError(exp.Token.Line, "unknown operator: binary %s not defined for %s and %s", exp.Operator, left, right)
+	return result
+}
+
+// checkComprehension checks the parts of a comprehension, with its variable in
+// scope for everything but the iterable
+func (c *checker) checkComprehension(exp *ast.Comprehension, s *scope) Type {
+	iterable := c.checkExpression(exp.Iterable, s)
+
+	inner := newScope(s)
+	inner.types[exp.Variable.Value] = Any
+	if array, ok := iterable.(*ArrayType); ok {
+		inner.types[exp.Variable.Value] = array.Element
+	}
+
+	if exp.Condition != nil {
+		c.checkExpression(exp.Condition, inner)
+	}
+	if exp.Key != nil {
+		c.checkExpression(exp.Key, inner)
+	}
+	element := c.checkExpression(exp.Element, inner)
+
+	if exp.Key == nil && exp.Token.Type == token.LeftBracket {
+		return &ArrayType{Element: element}
+	}
+	return Any
+}
+
+func (c *checker) checkFunctionLiteral(fn *ast.FunctionLiteral, s *scope) Type {
+	signature := functionSignature(fn)
+
+	inner := newScope(s)
+	for i, param := range fn.Parameters {
+		inner.types[param.Value] = signature.Parameters[i]
+	}
+
+	c.returns = append(c.returns, signature.Return)
+	result := c.checkBlock(fn.Body, inner)
+	c.returns = c.returns[:len(c.returns)-1]
+
+	statements := fn.Body.Statements
+	if len(statements) > 0 {
+		if last, ok := statements[len(statements)-1].(*ast.ExpressionStatement); ok {
+			if !assignable(result, signature.Return) {
+				c.addError(last.Token.Line, "function returning %s ends with %s", signature.Return, result)
+			}
+		}
+	}
+
+	return signature
+}
+
+func (c *checker) checkCallExpression(exp *ast.CallExpression, s *scope) Type {
+	callee := c.checkExpression(exp.Function, s)
+
+	args := []Type{}
+	for _, a := range exp.Arguments {
+		args = append(args, c.checkExpression(a, s))
+	}
+
+	fn, ok := callee.(*FunctionType)
+	if !ok {
+		if callee != Any {
+			c.addError(exp.Token.Line, "can not call %s", callee)
+		}
+		return Any
+	}
+
+	if fn.Parameters == nil {
+		return fn.Return
+	}
+
+	if len(fn.Parameters) != len(args) {
+		c.addError(exp.Token.Line, "incorrect number of arguments: need %d, got %d", len(fn.Parameters), len(args))
+		return fn.Return
+	}
+
+	for i, arg := range args {
+		if !assignable(arg, fn.Parameters[i]) {
+			c.addError(exp.Token.Line, "argument %d: can not use %s as %s", i+1, arg, fn.Parameters[i])
+		}
+	}
+
+	return fn.Return
+}
+
+func functionSignature(fn *ast.FunctionLiteral) *FunctionType {
+	params := []Type{}
+	for _, p := range fn.Parameters {
+		params = append(params, fromAnnotation(p.Type))
+	}
+
+	return &FunctionType{Parameters: params, Return: fromAnnotation(fn.ReturnType)}
+}
+
+func contains(types []Type, t Type) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package checker
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hummus-lang/lexer"
+	"hummus-lang/parser"
+	"testing"
+)
+
+func TestWellTypedPrograms(t *testing.T) {
+	tests := []string{
+		`let x: int = 5; x + 1;`,
+		`let greet = fn(name: string) -> string { "hello " + name }; greet("bob");`,
+		`let sum = fn(a: [int]) -> int { if (len(a) == 0) { return 0; } a[0] + sum(tail(a)) }; sum([1, 2, 3]);`,
+		`let apply = fn(f: fn(int) -> int, x: int) -> int { f(x) }; apply(fn(x: int) -> int { x * 2 }, 5);`,
+		`let untyped = fn(x) { x + 1 }; untyped("a");`,
+		`let x = 5; if (true) { let x = "a"; } x + 1;`,
+		`let half: float = 1 / 2.0; let x: float = 3; -half * x < 1;`,
+		`let xs: [int] = [x * 2 for x in [1, 2] if x > 1]; {"a": xs[0] + 1};`,
+		`let names = #{n + "!" for n in ["a"]}; (1, names, (x) => x.name);`,
+	}
+
+	for idx, input := range tests {
+		assert.Emptyf(t, check(t, input), "test case %d failed", idx)
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x: int = "five";`, "line 1: can not assign string to `x` of type int"},
+		{`1 + "a";`, "line 1: type mismatch: can not + int and string"},
+		{`true - false;`, "line 1: unknown operator: binary - not defined for bool and bool"},
+		{`-"a";`, "line 1: unknown operator: unary - not defined for string"},
+		{`let f = fn(a: string, b: [int]) -> bool { true }; f("a", ["b"]);`, "line 1: argument 2: can not use [string] as [int]"},
+		{`let f = fn(a: int) -> int { a }; f(1, 2);`, "line 1: incorrect number of arguments: need 1, got 2"},
+		{`let f = fn(a: int) -> bool { a };`, "line 1: function returning bool ends with int"},
+		{`let f = fn(a: int) -> bool { return a; };`, "line 1: can not return int from a function returning bool"},
+		{"let x: int = 5;\n\nx(3);", "line 3: can not call int"},
+		{`let xs: [int] = [1, 2]; xs["a"];`, "line 1: can not index [int] with string"},
+		{`1.5 + "a";`, "line 1: type mismatch: can not + float and string"},
+		{`1.5 & 1;`, "line 1: type mismatch: can not & float and int"},
+		{`let x: int = 1.5;`, "line 1: can not assign float to `x` of type int"},
+		{`[x + "a" for x in [1, 2]];`, "line 1: type mismatch: can not + int and string"},
+		{`[x for x in [1, 2] if -"a"];`, "line 1: unknown operator: unary - not defined for string"},
+		{`let xs: [string] = [x * 2 for x in [1]];`, "line 1: can not assign [int] to `xs` of type [string]"},
+		{`{"a": 1 + "b"};`, "line 1: type mismatch: can not + int and string"},
+		{`#{1, true - 1};`, "line 1: type mismatch: can not - bool and int"},
+		{`(1, (x: int) => x + "a");`, "line 1: type mismatch: can not + int and string"},
+		{`(1 + "a").name;`, "line 1: type mismatch: can not + int and string"},
+		{`let x = null; (1 + "a")?.name;`, "line 1: type mismatch: can not + int and string"},
+	}
+
+	for idx, tt := range tests {
+		errors := check(t, tt.input)
+		if assert.Lenf(t, errors, 1, "test case %d failed", idx) {
+			assert.Equalf(t, tt.expected, errors[0], "test case %d failed", idx)
+		}
+	}
+}
+
+func check(t *testing.T, input string) []string {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	return Check(program)
+}
@@ -0,0 +1,109 @@
+package checker
+
+import (
+	"hummus-lang/ast"
+	"strings"
+)
+
+type Type interface {
+	String() string
+}
+
+type basicType string
+
+func (bt basicType) String() string { return string(bt) }
+
+var (
+	Int    Type = basicType("int")
+	Float  Type = basicType("float")
+	String Type = basicType("string")
+	Bool   Type = basicType("bool")
+	Null   Type = basicType("null")
+
+	// Any is the type of everything that is not annotated and can not be
+	// worked out from literals. It is compatible with every other type
+	Any Type = basicType("any")
+)
+
+type ArrayType struct {
+	Element Type
+}
+
+func (at *ArrayType) String() string { return "[" + at.Element.String() + "]" }
+
+// FunctionType describes a callable value. Parameters is nil when the
+// arguments can not be checked, e.g. for predefs taking any number of them
+type FunctionType struct {
+	Parameters []Type
+	Return     Type
+}
+
+func (ft *FunctionType) String() string {
+	params := []string{}
+	for _, p := range ft.Parameters {
+		params = append(params, p.String())
+	}
+
+	return "fn(" + strings.Join(params, ", ") + ") -> " + ft.Return.String()
+}
+
+func fromAnnotation(annotation *ast.TypeAnnotation) Type {
+	if annotation == nil {
+		return Any
+	}
+
+	switch annotation.Name {
+	case "[]":
+		return &ArrayType{Element: fromAnnotation(annotation.Element)}
+	case "fn":
+		params := []Type{}
+		for _, p := range annotation.Parameters {
+			params = append(params, fromAnnotation(p))
+		}
+		return &FunctionType{Parameters: params, Return: fromAnnotation(annotation.Return)}
+	default:
+		return basicType(annotation.Name)
+	}
+}
+
+// assignable reports whether a value of type from can be used where a value
+// of type to is expected
+func assignable(from Type, to Type) bool {
+	if from == Any || to == Any {
+		return true
+	}
+
+	switch to := to.(type) {
+	case *ArrayType:
+		from, ok := from.(*ArrayType)
+		return ok && assignable(from.Element, to.Element)
+	case *FunctionType:
+		from, ok := from.(*FunctionType)
+		if !ok {
+			return false
+		}
+		if from.Parameters == nil || to.Parameters == nil {
+			return assignable(from.Return, to.Return)
+		}
+		if len(from.Parameters) != len(to.Parameters) {
+			return false
+		}
+		for i := range from.Parameters {
+			if !assignable(to.Parameters[i], from.Parameters[i]) {
+				return false
+			}
+		}
+		return assignable(from.Return, to.Return)
+	default:
+		// integers are used as floats wherever the two are mixed
+		return from == to || (from == Int && to == Float)
+	}
+}
+
+// join is the type of a value that may be either a or b
+func join(a Type, b Type) Type {
+	if a.String() == b.String() {
+		return a
+	}
+	return Any
+}
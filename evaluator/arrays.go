@@ -0,0 +1,442 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"math"
+)
+
+func init() {
+	predefs["map"] = &object.Predef{Function: mapElements}
+	predefs["filter"] = &object.Predef{Function: filter}
+	predefs["reduce"] = &object.Predef{Function: reduce}
+	predefs["each"] = &object.Predef{Function: each}
+	predefs["find"] = &object.Predef{Function: find}
+	predefs["any"] = &object.Predef{Function: anyElement}
+	predefs["all"] = &object.Predef{Function: allElements}
+	predefs["zip"] = &object.Predef{Function: zip}
+	predefs["flatten"] = &object.Predef{Function: flatten}
+	predefs["flatMap"] = &object.Predef{Function: flatMap}
+	predefs["groupBy"] = &object.Predef{Function: groupBy}
+	predefs["uniq"] = &object.Predef{Function: uniq}
+	predefs["take"] = &object.Predef{Function: slice("take", func(elements []object.Object, n int) []object.Object {
+		return elements[:n]
+	})}
+	predefs["drop"] = &object.Predef{Function: slice("drop", func(elements []object.Object, n int) []object.Object {
+		return elements[n:]
+	})}
+	predefs["concat"] = &object.Predef{Function: concat}
+	predefs["range"] = &object.Predef{Function: rangeOf}
+	predefs["sum"] = &object.Predef{Function: sum}
+}
+
+// call applies fn, treating a function that produced nothing, e.g. one ending
+// in a let statement, as having returned null
+func call(fn object.Object, args ...object.Object) object.Object {
+	result := applyFunction(fn, args)
+	if result == nil {
+		return Null
+	}
+	return result
+}
+
+// elementsArgument gets the elements of an array, tuple, set or string passed
+// to a predef
+func elementsArgument(name string, obj object.Object) ([]object.Object, *object.Error) {
+	elements, ok := iterableElements(obj)
+	if !ok {
+		return nil, newError("%s: can not iterate over `%s`", name, obj.Type())
+	}
+	return elements, nil
+}
+
+// withCallback checks the arguments of predefs taking a collection and a
+// function, and passes the collection's elements on to f
+func withCallback(name string, f func(elements []object.Object, fn object.Object) object.Object) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("%s: expected exactly 2 arguments. given %d", name, len(args))
+		}
+
+		elements, err := elementsArgument(name, args[0])
+		if err != nil {
+			return err
+		}
+
+		return f(elements, args[1])
+	}
+}
+
+var mapElements = withCallback("map", func(elements []object.Object, fn object.Object) object.Object {
+	results := make([]object.Object, len(elements))
+	for i, e := range elements {
+		result := call(fn, e)
+		if isError(result) {
+			return result
+		}
+		results[i] = result
+	}
+
+	return &object.Array{Elements: results}
+})
+
+var filter = withCallback("filter", func(elements []object.Object, fn object.Object) object.Object {
+	results := []object.Object{}
+	for _, e := range elements {
+		keep := call(fn, e)
+		if isError(keep) {
+			return keep
+		}
+		if isTruthy(keep) {
+			results = append(results, e)
+		}
+	}
+
+	return &object.Array{Elements: results}
+})
+
+// reduce folds the elements into a single value, calling fn(accumulator,
+// element) for each element in turn
+func reduce(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("reduce: expected exactly 3 arguments. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("reduce", args[0])
+	if err != nil {
+		return err
+	}
+
+	accumulator := args[1]
+	for _, e := range elements {
+		accumulator = call(args[2], accumulator, e)
+		if isError(accumulator) {
+			return accumulator
+		}
+	}
+
+	return accumulator
+}
+
+var each = withCallback("each", func(elements []object.Object, fn object.Object) object.Object {
+	for _, e := range elements {
+		result := call(fn, e)
+		if isError(result) {
+			return result
+		}
+	}
+
+	return Null
+})
+
+// find returns the first element fn accepts, or null if there is none
+var find = withCallback("find", func(elements []object.Object, fn object.Object) object.Object {
+	for _, e := range elements {
+		found := call(fn, e)
+		if isError(found) {
+			return found
+		}
+		if isTruthy(found) {
+			return e
+		}
+	}
+
+	return Null
+})
+
+var anyElement = withCallback("any", func(elements []object.Object, fn object.Object) object.Object {
+	for _, e := range elements {
+		result := call(fn, e)
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			return True
+		}
+	}
+
+	return False
+})
+
+var allElements = withCallback("all", func(elements []object.Object, fn object.Object) object.Object {
+	for _, e := range elements {
+		result := call(fn, e)
+		if isError(result) {
+			return result
+		}
+		if !isTruthy(result) {
+			return False
+		}
+	}
+
+	return True
+})
+
+// zip pairs up the elements of two collections as tuples, stopping at the end
+// of the shorter one
+func zip(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("zip: expected exactly 2 arguments. given %d", len(args))
+	}
+
+	left, err := elementsArgument("zip", args[0])
+	if err != nil {
+		return err
+	}
+	right, err := elementsArgument("zip", args[1])
+	if err != nil {
+		return err
+	}
+
+	n := len(left)
+	if len(right) < n {
+		n = len(right)
+	}
+
+	pairs := make([]object.Object, n)
+	for i := 0; i < n; i++ {
+		pairs[i] = &object.Tuple{Elements: []object.Object{left[i], right[i]}}
+	}
+
+	return &object.Array{Elements: pairs}
+}
+
+// flatten removes one level of nesting, so [[1], 2, [3, [4]]] becomes
+// [1, 2, 3, [4]]
+func flatten(args ...object.Object) object.Object {
+	if err := checkArguments("flatten", args, object.ArrayObj); err != nil {
+		return err
+	}
+
+	results := []object.Object{}
+	for _, e := range args[0].(*object.Array).Elements {
+		if inner, ok := e.(*object.Array); ok {
+			results = append(results, inner.Elements...)
+		} else {
+			results = append(results, e)
+		}
+	}
+
+	return &object.Array{Elements: results}
+}
+
+var flatMap = withCallback("flatMap", func(elements []object.Object, fn object.Object) object.Object {
+	results := []object.Object{}
+	for _, e := range elements {
+		result := call(fn, e)
+		if isError(result) {
+			return result
+		}
+
+		inner, ok := result.(*object.Array)
+		if !ok {
+			return newError("flatMap: function must return `ARRAY`, got `%s`", result.Type())
+		}
+		results = append(results, inner.Elements...)
+	}
+
+	return &object.Array{Elements: results}
+})
+
+// groupBy collects elements by the key fn gives them. The groups come back as
+// a hash from each key to an array of its elements, in the order the keys
+// were first seen
+var groupBy = withCallback("groupBy", func(elements []object.Object, fn object.Object) object.Object {
+	groups := map[object.HashKey]*object.Array{}
+	results := object.NewHash()
+
+	for _, e := range elements {
+		key := call(fn, e)
+		if isError(key) {
+			return key
+		}
+
+		hashKey, ok := object.HashKeyOf(key)
+		if !ok {
+			return newError("groupBy: can not group by `%s`", key.Type())
+		}
+
+		group, ok := groups[hashKey]
+		if !ok {
+			group = &object.Array{Elements: []object.Object{}}
+			groups[hashKey] = group
+			results.Set(key, group)
+		}
+		group.Elements = append(group.Elements, e)
+	}
+
+	return results
+})
+
+// uniq removes repeated elements, keeping the first of each
+func uniq(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("uniq: expected exactly 1 argument. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("uniq", args[0])
+	if err != nil {
+		return err
+	}
+
+	seen := map[object.HashKey]bool{}
+	unhashable := []object.Object{}
+	results := []object.Object{}
+
+	for _, e := range elements {
+		if key, ok := object.HashKeyOf(e); ok {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+		} else {
+			duplicate := false
+			for _, u := range unhashable {
+				if objectsEqual(e, u) {
+					duplicate = true
+					break
+				}
+			}
+			if duplicate {
+				continue
+			}
+			unhashable = append(unhashable, e)
+		}
+
+		results = append(results, e)
+	}
+
+	return &object.Array{Elements: results}
+}
+
+// slice builds take and drop, clamping the count to the number of elements
+func slice(name string, f func(elements []object.Object, n int) []object.Object) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("%s: expected exactly 2 arguments. given %d", name, len(args))
+		}
+
+		elements, err := elementsArgument(name, args[0])
+		if err != nil {
+			return err
+		}
+
+		count, ok := args[1].(*object.Integer)
+		if !ok {
+			return newError("%s: argument 2 must be `INTEGER`, got `%s`", name, args[1].Type())
+		}
+		if count.Value < 0 {
+			return newError("%s: count must not be negative. given %d", name, count.Value)
+		}
+
+		n := len(elements)
+		if count.Value < int64(n) {
+			n = int(count.Value)
+		}
+
+		return &object.Array{Elements: append([]object.Object{}, f(elements, n)...)}
+	}
+}
+
+func concat(args ...object.Object) object.Object {
+	results := []object.Object{}
+	for i, a := range args {
+		array, ok := a.(*object.Array)
+		if !ok {
+			return newError("concat: argument %d must be `ARRAY`, got `%s`", i+1, a.Type())
+		}
+		results = append(results, array.Elements...)
+	}
+
+	return &object.Array{Elements: results}
+}
+
+// rangeOf counts from start up to, but not including, end. It is called as
+// range(end), range(start, end) or range(start, end, step)
+func rangeOf(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("range: expected 1 to 3 arguments. given %d", len(args))
+	}
+
+	bounds := []int64{}
+	for i, a := range args {
+		n, ok := a.(*object.Integer)
+		if !ok {
+			return newError("range: argument %d must be `INTEGER`, got `%s`", i+1, a.Type())
+		}
+		bounds = append(bounds, n.Value)
+	}
+
+	start, end, step := int64(0), bounds[0], int64(1)
+	if len(bounds) > 1 {
+		start, end = bounds[0], bounds[1]
+	}
+	if len(bounds) > 2 {
+		step = bounds[2]
+	}
+
+	if step == 0 {
+		return newError("range: step must not be 0")
+	}
+	if n := rangeLength(start, end, step); n > maxRangeLength {
+		return newError("range: can not make more than %d numbers. given a range of %d", maxRangeLength, n)
+	}
+
+	results := []object.Object{}
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		results = append(results, &object.Integer{Value: i})
+
+		// stop rather than wrap around when the next step would overflow
+		if (step > 0 && i > math.MaxInt64-step) || (step < 0 && i < math.MinInt64-step) {
+			break
+		}
+	}
+
+	return &object.Array{Elements: results}
+}
+
+// maxRangeLength is the most numbers range will count out, so a mistaken
+// bound fails rather than exhausting memory
+const maxRangeLength = 1 << 22
+
+// rangeLength works out how many numbers range would count out, in unsigned
+// arithmetic so that no range of int64s can overflow it
+func rangeLength(start int64, end int64, step int64) uint64 {
+	var span, stride uint64
+	switch {
+	case step > 0 && start < end:
+		span, stride = uint64(end)-uint64(start), uint64(step)
+	case step < 0 && start > end:
+		span, stride = uint64(start)-uint64(end), uint64(-(step+1))+1
+	default:
+		return 0
+	}
+
+	n := span / stride
+	if span%stride != 0 {
+		n++
+	}
+	return n
+}
+
+// sum adds up an array of numbers. The total is an integer unless any of
+// them is a float
+func sum(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("sum: expected exactly 1 argument. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("sum", args[0])
+	if err != nil {
+		return err
+	}
+
+	var total object.Object = &object.Integer{Value: 0}
+	for _, e := range elements {
+		if _, ok := floatValue(e); !ok {
+			return newError("sum: can only add up numbers, got `%s`", e.Type())
+		}
+		total = evalInfixExpression("+", total, e)
+	}
+
+	return total
+}
@@ -0,0 +1,237 @@
+package evaluator
+
+import (
+	"hummus-lang/ast"
+	"hummus-lang/object"
+	"hummus-lang/token"
+	"strings"
+)
+
+func init() {
+	predefs["union"] = &object.Predef{Function: setOperation("union", func(a, b *object.Set, obj object.Object) bool {
+		return true
+	})}
+	predefs["intersect"] = &object.Predef{Function: setOperation("intersect", func(a, b *object.Set, obj object.Object) bool {
+		return a.Contains(obj) && b.Contains(obj)
+	})}
+	predefs["difference"] = &object.Predef{Function: setOperation("difference", func(a, b *object.Set, obj object.Object) bool {
+		return a.Contains(obj) && !b.Contains(obj)
+	})}
+	predefs["contains"] = &object.Predef{Function: contains}
+	predefs["keys"] = &object.Predef{Function: hashItems("keys", func(pair object.HashPair) object.Object {
+		return pair.Key
+	})}
+	predefs["values"] = &object.Predef{Function: hashItems("values", func(pair object.HashPair) object.Object {
+		return pair.Value
+	})}
+}
+
+func evalSetLiteral(node *ast.SetLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	set := object.NewSet()
+	for _, e := range elements {
+		if !set.Add(e) {
+			return newError("set: can not use `%s` as a set element", e.Type())
+		}
+	}
+
+	return set
+}
+
+func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
+	hash := object.NewHash()
+
+	for i, keyNode := range node.Keys {
+		key := Eval(keyNode, env)
+		if isError(key) {
+			return key
+		}
+
+		value := Eval(node.Values[i], env)
+		if isError(value) {
+			return value
+		}
+
+		if !hash.Set(key, value) {
+			return newError("hash: can not use `%s` as a hash key", key.Type())
+		}
+	}
+
+	return hash
+}
+
+func evalTupleLiteral(node *ast.TupleLiteral, env *object.Environment) object.Object {
+	elements := evalExpressions(node.Elements, env)
+	if len(elements) == 1 && isError(elements[0]) {
+		return elements[0]
+	}
+
+	return &object.Tuple{Elements: elements}
+}
+
+// iterableElements returns the values a comprehension iterates over. Hashes
+// are iterated over by key
+func iterableElements(obj object.Object) ([]object.Object, bool) {
+	switch x := obj.(type) {
+	case *object.Hash:
+		keys := []object.Object{}
+		for _, pair := range x.Items() {
+			keys = append(keys, pair.Key)
+		}
+		return keys, true
+	case *object.Array:
+		return x.Elements, true
+	case *object.Tuple:
+		return x.Elements, true
+	case *object.Set:
+		return x.Items(), true
+	case *object.String:
+		chars := []object.Object{}
+		for _, r := range x.Value {
+			chars = append(chars, &object.String{Value: string(r)})
+		}
+		return chars, true
+	default:
+		return nil, false
+	}
+}
+
+// evalComprehension evaluates each iteration in its own environment, so the
+// loop variable does not leak and closures capture the value of their own
+// iteration
+func evalComprehension(node *ast.Comprehension, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	items, ok := iterableElements(iterable)
+	if !ok {
+		return newError("comprehension: can not iterate over `%s` (line %d)", iterable.Type(), node.Token.Line)
+	}
+
+	keys, results := []object.Object{}, []object.Object{}
+	for _, item := range items {
+		iterationEnv := object.NewEnclosedEnvironment(env)
+		iterationEnv.Set(node.Variable.Value, item)
+
+		if node.Condition != nil {
+			condition := Eval(node.Condition, iterationEnv)
+			if isError(condition) {
+				return condition
+			}
+			if !isTruthy(condition) {
+				continue
+			}
+		}
+
+		if node.Key != nil {
+			key := Eval(node.Key, iterationEnv)
+			if isError(key) {
+				return key
+			}
+			keys = append(keys, key)
+		}
+
+		element := Eval(node.Element, iterationEnv)
+		if isError(element) {
+			return element
+		}
+		results = append(results, element)
+	}
+
+	if node.Key != nil {
+		// later values replace earlier ones with the same key, as in a literal
+		hash := object.NewHash()
+		for i, key := range keys {
+			if !hash.Set(key, results[i]) {
+				return newError("hash: can not use `%s` as a hash key", key.Type())
+			}
+		}
+		return hash
+	}
+
+	if node.Token.Type != token.SetBrace {
+		return &object.Array{Elements: results}
+	}
+
+	set := object.NewSet()
+	for _, e := range results {
+		if !set.Add(e) {
+			return newError("set: can not use `%s` as a set element", e.Type())
+		}
+	}
+
+	return set
+}
+
+// setOperation builds a predef combining two sets, keeping the elements of
+// either set for which keep returns true
+func setOperation(name string, keep func(a, b *object.Set, obj object.Object) bool) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return newError("%s: expected exactly 2 arguments. given %d", name, len(args))
+		}
+
+		a, ok := args[0].(*object.Set)
+		if !ok {
+			return newError("%s: can only combine sets, got `%s`", name, args[0].Type())
+		}
+		b, ok := args[1].(*object.Set)
+		if !ok {
+			return newError("%s: can only combine sets, got `%s`", name, args[1].Type())
+		}
+
+		result := object.NewSet()
+		for _, obj := range append(a.Items(), b.Items()...) {
+			if keep(a, b, obj) {
+				result.Add(obj)
+			}
+		}
+
+		return result
+	}
+}
+
+func contains(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("contains: expected exactly 2 arguments. given %d", len(args))
+	}
+
+	switch x := args[0].(type) {
+	case *object.Set:
+		return nativeBoolToBooleanObject(x.Contains(args[1]))
+	case *object.Hash:
+		_, ok := x.Get(args[1])
+		return nativeBoolToBooleanObject(ok)
+	case *object.String:
+		substring, ok := args[1].(*object.String)
+		if !ok {
+			return newError("contains: can only search for a `STRING` in a string, got `%s`", args[1].Type())
+		}
+		return nativeBoolToBooleanObject(strings.Contains(x.Value, substring.Value))
+	default:
+		return newError("contains: can not search in `%s`", args[0].Type())
+	}
+}
+
+// hashItems builds keys and values, which list part of each pair of a hash in
+// the order they were added
+func hashItems(name string, part func(pair object.HashPair) object.Object) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if err := checkArguments(name, args, object.HashObj); err != nil {
+			return err
+		}
+
+		items := []object.Object{}
+		for _, pair := range args[0].(*object.Hash).Items() {
+			items = append(items, part(pair))
+		}
+
+		return &object.Array{Elements: items}
+	}
+}
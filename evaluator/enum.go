@@ -0,0 +1,205 @@
+package evaluator
+
+import (
+	"hummus-lang/ast"
+	"hummus-lang/object"
+	"strings"
+)
+
+var overloadableOperators = map[string]bool{
+	"+":  true,
+	"-":  true,
+	"*":  true,
+	"/":  true,
+	"%":  true,
+	"==": true,
+	"<":  true,
+	">":  true,
+	"[]": true,
+}
+
+func init() {
+	predefs["match"] = &object.Predef{Function: match}
+	predefs["overload"] = &object.Predef{Function: overload}
+}
+
+func evalEnumStatement(node *ast.EnumStatement, env *object.Environment) object.Object {
+	enum := &object.Enum{Name: node.Name.Value, Operators: map[string]object.Object{}}
+
+	for _, v := range node.Variants {
+		fields := []string{}
+		for _, f := range v.Fields {
+			fields = append(fields, f.Value)
+		}
+
+		enum.Variants = append(enum.Variants, &object.VariantType{Enum: enum, Name: v.Name.Value, Fields: fields})
+	}
+
+	result := env.Set(node.Name.Value, enum)
+	if isError(result) {
+		return result
+	}
+
+	return nil
+}
+
+// enumMember looks up a variant by name. Variants without fields are values
+// in their own right; the others are constructors
+func enumMember(enum *object.Enum, name string) (object.Object, bool) {
+	variant := enum.Variant(name)
+	if variant == nil {
+		return nil, false
+	}
+
+	if len(variant.Fields) == 0 {
+		return &object.Variant{Variant: variant, Values: []object.Object{}}, true
+	}
+
+	return variant, true
+}
+
+func constructVariant(variant *object.VariantType, args []object.Object) object.Object {
+	if len(variant.Fields) != len(args) {
+		return newError("incorrect number of arguments: need %d, got %d", len(variant.Fields), len(args))
+	}
+
+	values := make([]object.Object, len(args))
+	copy(values, args)
+
+	return &object.Variant{Variant: variant, Values: values}
+}
+
+// overload registers a function implementing an operator for the variants of
+// an enum, e.g. `overload(Money, "+", fn(a, b) { ... })`. Binary operators
+// are passed both operands, indexing ("[]") is passed the value and the index
+func overload(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("overload: expected exactly 3 arguments. given %d", len(args))
+	}
+
+	enum, ok := args[0].(*object.Enum)
+	if !ok {
+		return newError("overload: can only overload operators of enums, got `%s`", args[0].Type())
+	}
+
+	operator, ok := args[1].(*object.String)
+	if !ok || !overloadableOperators[operator.Value] {
+		return newError("overload: can not overload `%s`", args[1].Printable())
+	}
+
+	switch args[2].(type) {
+	case *object.Function, *object.Predef:
+		enum.Operators[operator.Value] = args[2]
+		return Null
+	default:
+		return newError("overload: handler must be a function, got `%s`", args[2].Type())
+	}
+}
+
+// operatorHandler finds the user defined function for operator, looking at the
+// left operand first and then the right
+func operatorHandler(operator string, operands ...object.Object) (object.Object, bool) {
+	for _, operand := range operands {
+		if variant, ok := operand.(*object.Variant); ok {
+			if handler, ok := variant.Variant.Enum.Operators[operator]; ok {
+				return handler, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// evalOverloadedInfixExpression applies a user defined operator if either
+// operand has one. `!=` is the negation of an overloaded `==`
+func evalOverloadedInfixExpression(operator string, left object.Object, right object.Object) (object.Object, bool) {
+	if operator == "!=" {
+		handler, ok := operatorHandler("==", left, right)
+		if !ok {
+			return nil, false
+		}
+
+		result := applyFunction(handler, []object.Object{left, right})
+		if isError(result) {
+			return result, true
+		}
+		return nativeBoolToBooleanObject(!isTruthy(result)), true
+	}
+
+	handler, ok := operatorHandler(operator, left, right)
+	if !ok {
+		return nil, false
+	}
+
+	return applyFunction(handler, []object.Object{left, right}), true
+}
+
+// objectsEqual compares values structurally, using the overloaded `==` of
+// any enum values met along the way
+func objectsEqual(a object.Object, b object.Object) bool {
+	return object.EqualFunc(a, b, func(a object.Object, b object.Object) (bool, bool) {
+		handler, ok := operatorHandler("==", a, b)
+		if !ok {
+			return false, false
+		}
+
+		result := applyFunction(handler, []object.Object{a, b})
+		return !isError(result) && isTruthy(result), true
+	})
+}
+
+// match calls the handler for the variant of its first argument, passing it
+// the variant's fields. Handlers are given as pairs of variant name and
+// function, with "_" matching any variant not otherwise handled. Every
+// variant of the enum must be handled, whichever one is being matched
+func match(args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return newError("match: expected at least 1 argument. given %d", len(args))
+	}
+
+	value, ok := args[0].(*object.Variant)
+	if !ok {
+		return newError("match: can only match on enum variants, got `%s`", args[0].Type())
+	}
+
+	handlers := args[1:]
+	if len(handlers)%2 != 0 {
+		return newError("match: expected pairs of variant name and handler")
+	}
+
+	enum := value.Variant.Enum
+	handled := map[string]object.Object{}
+
+	for i := 0; i < len(handlers); i += 2 {
+		name, ok := handlers[i].(*object.String)
+		if !ok {
+			return newError("match: variant name must be a `STRING`, got `%s`", handlers[i].Type())
+		}
+
+		if name.Value != "_" && enum.Variant(name.Value) == nil {
+			return newError("match: `%s` has no variant `%s`", enum.Name, name.Value)
+		}
+
+		handled[name.Value] = handlers[i+1]
+	}
+
+	if fallback, ok := handled["_"]; ok {
+		if handler, ok := handled[value.Variant.Name]; ok {
+			return applyFunction(handler, value.Values)
+		}
+		return applyFunction(fallback, []object.Object{value})
+	}
+
+	unhandled := []string{}
+	for _, v := range enum.Variants {
+		if _, ok := handled[v.Name]; !ok {
+			unhandled = append(unhandled, v.Name)
+		}
+	}
+
+	if len(unhandled) > 0 {
+		return newError("match: unhandled variants of `%s`: %s", enum.Name, strings.Join(unhandled, ", "))
+	}
+
+	return applyFunction(handled[value.Variant.Name], value.Values)
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"hummus-lang/ast"
 	"hummus-lang/object"
+	"math"
+	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -15,13 +18,13 @@ var (
 var predefs = map[string]*object.Predef{
 	"print": &object.Predef{
 		Function: func(args ...object.Object) object.Object {
-			fmt.Print(args[0].Printable())
+			fmt.Print(printable(args))
 			return Null
 		},
 	},
 	"printLine": &object.Predef{
 		Function: func(args ...object.Object) object.Object {
-			fmt.Println(args[0].Printable())
+			fmt.Println(printable(args))
 			return Null
 		},
 	},
@@ -32,11 +35,17 @@ var predefs = map[string]*object.Predef{
 			}
 			switch x := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(x.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(x.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(x.Elements))}
+			case *object.Tuple:
+				return &object.Integer{Value: int64(len(x.Elements))}
+			case *object.Set:
+				return &object.Integer{Value: int64(len(x.Elements))}
+			case *object.Hash:
+				return &object.Integer{Value: int64(len(x.Pairs))}
 			default:
-				return newError("len: can only take length of strings and arrays")
+				return newError("len: can only take length of strings, arrays, tuples, sets and hashes")
 			}
 		},
 	},
@@ -56,7 +65,8 @@ var predefs = map[string]*object.Predef{
 				if len(x.Value) == 0 {
 					return newError("head: can not take head of empty string")
 				} else {
-					return &object.String{Value: fmt.Sprintf("%c", x.Value[0])}
+					first, _ := utf8.DecodeRuneInString(x.Value)
+					return &object.String{Value: string(first)}
 				}
 			default:
 				return newError("head: can not take head of `%s`", args[0].Type())
@@ -73,20 +83,101 @@ var predefs = map[string]*object.Predef{
 				if len(x.Elements) == 0 {
 					return newError("tail: can not take tail of empty array")
 				} else {
-					tailElements := x.Elements[1:]
-					return &object.Array{Elements: tailElements}
+					tailElements := append([]object.Object{}, x.Elements[1:]...)
+					return &object.Array{Elements: tailElements, Frozen: x.Frozen}
 				}
 			case *object.String:
 				if len(x.Value) == 0 {
 					return newError("tail: can not take tail of empty string")
 				} else {
-					return &object.String{Value: x.Value[1:]}
+					_, size := utf8.DecodeRuneInString(x.Value)
+					return &object.String{Value: x.Value[size:]}
 				}
 			default:
 				return newError("head: can not take head of `%s`", args[0].Type())
 			}
 		},
 	},
+	"same": &object.Predef{
+		Function: func(args ...object.Object) object.Object {
+			if len(args) != 2 {
+				return newError("same: expected exactly 2 arguments. given %d", len(args))
+			}
+			return nativeBoolToBooleanObject(same(args[0], args[1]))
+		},
+	},
+	"freeze": &object.Predef{
+		Function: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("freeze: expected exactly 1 argument. given %d", len(args))
+			}
+			freeze(args[0])
+			return args[0]
+		},
+	},
+}
+
+// printable joins what print and printLine were given with spaces
+func printable(args []object.Object) string {
+	values := make([]string, len(args))
+	for i, a := range args {
+		values[i] = a.Printable()
+	}
+	return strings.Join(values, " ")
+}
+
+// same reports whether a and b are the same object. Numbers, strings,
+// booleans and null have no identity of their own, so they are the same when
+// they are equal
+func same(a object.Object, b object.Object) bool {
+	switch a.(type) {
+	case *object.Integer, *object.Float, *object.String, *object.Boolean, *object.Null:
+		return object.Equal(a, b)
+	default:
+		return a == b
+	}
+}
+
+// modules are namespaces of predefs, such as `math`, looked up after predefs
+var modules = map[string]*object.Module{}
+
+// freeze marks obj and everything reachable from it as read-only
+func freeze(obj object.Object) {
+	switch x := obj.(type) {
+	case *object.Array:
+		if x.Frozen {
+			return
+		}
+		x.Frozen = true
+		for _, e := range x.Elements {
+			freeze(e)
+		}
+	case *object.Tuple:
+		for _, e := range x.Elements {
+			freeze(e)
+		}
+	case *object.Variant:
+		for _, v := range x.Values {
+			freeze(v)
+		}
+	case *object.Set:
+		if x.Frozen {
+			return
+		}
+		x.Frozen = true
+		for _, e := range x.Items() {
+			freeze(e)
+		}
+	case *object.Hash:
+		if x.Frozen {
+			return
+		}
+		x.Frozen = true
+		for _, pair := range x.Items() {
+			freeze(pair.Key)
+			freeze(pair.Value)
+		}
+	}
 }
 
 func newError(format string, a ...interface{}) *object.Error {
@@ -151,6 +242,14 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
+	if f, ok := right.(*object.Float); ok {
+		return &object.Float{Value: -f.Value}
+	}
+
+	if d, ok := right.(*object.Duration); ok {
+		return &object.Duration{Value: -d.Value}
+	}
+
 	if right.Type() != object.IntegerObj {
 		return newError("unknown operator: unary - not defined for `%s`", right.Type())
 	}
@@ -174,6 +273,22 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 		return &object.Integer{Value: leftVal / rightVal}
 	case "%":
 		return &object.Integer{Value: leftVal % rightVal}
+	case "&":
+		return &object.Integer{Value: leftVal & rightVal}
+	case "|":
+		return &object.Integer{Value: leftVal | rightVal}
+	case "^":
+		return &object.Integer{Value: leftVal ^ rightVal}
+	case "<<":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+		return &object.Integer{Value: leftVal << uint64(rightVal)}
+	case ">>":
+		if rightVal < 0 {
+			return newError("negative shift count: %d", rightVal)
+		}
+		return &object.Integer{Value: leftVal >> uint64(rightVal)}
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 	case ">":
@@ -187,6 +302,57 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
+// evalFloatInfixExpression handles arithmetic on floats, and on a float and an
+// integer, which is treated as a float
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal, _ := floatValue(left)
+	rightVal, _ := floatValue(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "==":
+		// compared exactly, as inside arrays or as hash keys, rather than
+		// after converting large integers to floats
+		return nativeBoolToBooleanObject(object.Equal(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!object.Equal(left, right))
+	default:
+		return newError("unknown operator: binary %s not defined for `%s` and `%s`", operator, left.Type(), right.Type())
+	}
+}
+
+// floatValue converts a number to a float
+func floatValue(obj object.Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *object.Float:
+		return obj.Value, true
+	case *object.Integer:
+		return float64(obj.Value), true
+	default:
+		return 0, false
+	}
+}
+
+func isFloatArithmetic(left object.Object, right object.Object) bool {
+	_, leftNumber := floatValue(left)
+	_, rightNumber := floatValue(right)
+
+	return leftNumber && rightNumber && (left.Type() == object.FloatObj || right.Type() == object.FloatObj)
+}
+
 func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftString := left.(*object.String).Value
 	rightString := right.(*object.String).Value
@@ -194,32 +360,55 @@ func evalStringInfixExpression(operator string, left object.Object, right object
 	switch operator {
 	case "+":
 		return &object.String{Value: leftString + rightString}
+	case "==":
+		return nativeBoolToBooleanObject(leftString == rightString)
+	case "!=":
+		return nativeBoolToBooleanObject(leftString != rightString)
 	default:
 		return newError("unknown operator: binary %s not defined for `%s` and `%s`", operator, left.Type(), right.Type())
 	}
 }
 
+func evalTildePrefixOperatorExpression(right object.Object) object.Object {
+	if right.Type() != object.IntegerObj {
+		return newError("unknown operator: unary ~ not defined for `%s`", right.Type())
+	}
+
+	value := right.(*object.Integer).Value
+	return &object.Integer{Value: ^value}
+}
+
 func evalPrefixExpression(operator string, right object.Object) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
 		return evalMinusPrefixOperatorExpression(right)
+	case "~":
+		return evalTildePrefixOperatorExpression(right)
 	default:
 		return newError("unknown operator: %s not defined for type `%s`", operator, right.Type())
 	}
 }
 
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	if result, ok := evalOverloadedInfixExpression(operator, left, right); ok {
+		return result
+	}
+
 	switch {
 	case left.Type() == object.StringObj && right.Type() == object.StringObj:
 		return evalStringInfixExpression(operator, left, right)
 	case left.Type() == object.IntegerObj && right.Type() == object.IntegerObj:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isFloatArithmetic(left, right):
+		return evalFloatInfixExpression(operator, left, right)
 	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
+		return nativeBoolToBooleanObject(objectsEqual(left, right))
 	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
+		return nativeBoolToBooleanObject(!objectsEqual(left, right))
+	case isTimeArithmetic(left, right):
+		return evalTimeInfixExpression(operator, left, right)
 	case left.Type() != right.Type():
 		return newError("type mismatch: can not %s `%s` and `%s`", operator, left.Type(), right.Type())
 	default:
@@ -248,11 +437,19 @@ func evalIndexExpression(ie *ast.IndexExpression, env *object.Environment) objec
 		return leftEval
 	}
 
+	if ie.Optional && isNull(leftEval) {
+		return Null
+	}
+
 	rightEval := Eval(ie.Right, env)
 	if isError(rightEval) {
 		return rightEval
 	}
 
+	if handler, ok := operatorHandler("[]", leftEval); ok {
+		return applyFunction(handler, []object.Object{leftEval, rightEval})
+	}
+
 	if leftEval.Type() == object.ArrayObj && rightEval.Type() == object.IntegerObj {
 		array := leftEval.(*object.Array).Elements
 		idx := int(rightEval.(*object.Integer).Value)
@@ -263,20 +460,90 @@ func evalIndexExpression(ie *ast.IndexExpression, env *object.Environment) objec
 		}
 
 		return array[idx]
-	} else if leftEval.Type() == object.StringObj && rightEval.Type() == object.IntegerObj {
-		str := leftEval.(*object.String).Value
+	} else if leftEval.Type() == object.TupleObj && rightEval.Type() == object.IntegerObj {
+		elements := leftEval.(*object.Tuple).Elements
 		idx := int(rightEval.(*object.Integer).Value)
 
-		if idx < 0 || idx > len(str)-1 {
+		if idx < 0 || idx > len(elements)-1 {
+			return newError("index expression: index out of tuple bounds")
+		}
+
+		return elements[idx]
+	} else if hash, ok := leftEval.(*object.Hash); ok {
+		if _, hashable := object.HashKeyOf(rightEval); !hashable {
+			return newError("index expression: can not use `%s` as a hash key", rightEval.Type())
+		}
+
+		if value, ok := hash.Get(rightEval); ok {
+			return value
+		}
+		return Null
+	} else if leftEval.Type() == object.StringObj && rightEval.Type() == object.IntegerObj {
+		// strings are indexed by character, as len counts them
+		str := []rune(leftEval.(*object.String).Value)
+		idx := rightEval.(*object.Integer).Value
+
+		if idx < 0 || idx > int64(len(str))-1 {
 			return newError("index expression: index out of string bounds")
 		}
 
-		return &object.String{Value: fmt.Sprintf("%c", str[idx])}
+		return &object.String{Value: string(str[idx])}
 	}
 
 	return newError("index expression: can not take index of type `%s` with `%s`", leftEval.Type(), rightEval.Type())
 }
 
+func evalMemberExpression(me *ast.MemberExpression, env *object.Environment) object.Object {
+	left := Eval(me.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if me.Optional && isNull(left) {
+		return Null
+	}
+
+	if left == nil {
+		left = Null
+	}
+
+	switch left := left.(type) {
+	case *object.Enum:
+		if member, ok := enumMember(left, me.Property.Value); ok {
+			return member
+		}
+	case *object.Variant:
+		if value, ok := left.Field(me.Property.Value); ok {
+			return value
+		}
+	case *object.Module:
+		if member, ok := left.Members[me.Property.Value]; ok {
+			return member
+		}
+	case *object.Hash:
+		// h.name is h["name"], so a missing key is null just as it is there
+		if value, ok := left.Get(&object.String{Value: me.Property.Value}); ok {
+			return value
+		}
+		return Null
+	}
+
+	return newError("member expression: `%s` has no member `%s` (line %d)", left.Type(), me.Property.Value, me.Token.Line)
+}
+
+func evalCoalesceExpression(ie *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(ie.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	if !isNull(left) {
+		return left
+	}
+
+	return Eval(ie.Right, env)
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
 	val, ok := env.Get(node.Value)
 	if ok {
@@ -288,6 +555,11 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
+	module, ok := modules[node.Value]
+	if ok {
+		return module
+	}
+
 	return newError("unknown reference on line %d: %s", node.Token.Line, node.Value)
 
 }
@@ -321,6 +593,9 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 		actual := fn.(*object.Predef)
 		return actual.Function(args...)
 
+	case *object.VariantType:
+		return constructVariant(fn.(*object.VariantType), args)
+
 	default:
 		return newError("applyFunction: unknown function; got %s", fn.Type())
 	}
@@ -352,7 +627,16 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+
+		var result object.Object
+		if node.Constant {
+			result = env.SetConstant(node.Name.Value, val)
+		} else {
+			result = env.Set(node.Name.Value, val)
+		}
+		if isError(result) {
+			return result
+		}
 
 	case *ast.Program:
 		return evalProgram(node.Statements, env)
@@ -363,9 +647,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.RegexLiteral:
+		return &object.Regex{Pattern: node.Pattern, Flags: node.Flags, Regexp: node.Regexp}
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
@@ -374,6 +664,10 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalPrefixExpression(node.Operator, right)
 
 	case *ast.InfixExpression:
+		if node.Operator == "??" {
+			return evalCoalesceExpression(node, env)
+		}
+
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -432,6 +726,27 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IndexExpression:
 		return evalIndexExpression(node, env)
 
+	case *ast.MemberExpression:
+		return evalMemberExpression(node, env)
+
+	case *ast.EnumStatement:
+		return evalEnumStatement(node, env)
+
+	case *ast.HashLiteral:
+		return evalHashLiteral(node, env)
+
+	case *ast.SetLiteral:
+		return evalSetLiteral(node, env)
+
+	case *ast.TupleLiteral:
+		return evalTupleLiteral(node, env)
+
+	case *ast.Comprehension:
+		return evalComprehension(node, env)
+
+	case *ast.NullLiteral:
+		return Null
+
 	default:
 		fmt.Printf("Eval: unknown expression type encountered. Expression type: %T\n", node)
 	}
@@ -447,6 +762,12 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	}
 }
 
+// isNull reports whether obj is null. Statements such as let evaluate to nil,
+// which scripts see as null too
+func isNull(obj object.Object) bool {
+	return obj == nil || obj == Null
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case Null:
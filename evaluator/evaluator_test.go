@@ -6,7 +6,13 @@ import (
 	"hummus-lang/lexer"
 	"hummus-lang/object"
 	"hummus-lang/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -25,6 +31,16 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"5 + 2 * 10", 25},
 		{"20 * -10", -200},
 		{"15 % 4", 3},
+		{"0xff", 255},
+		{"0b1100 & 0b1010", 8},
+		{"0b1100 | 0b1010", 14},
+		{"0b1100 ^ 0b1010", 6},
+		{"~0", -1},
+		{"1 << 4", 16},
+		{"256 >> 4", 16},
+		{"1 << 2 + 1", 8},
+		{"0x0f & 0xf0 | 0x01", 1},
+		{"1_000 + 0o10", 1008},
 	}
 
 	for _, tt := range tests {
@@ -46,6 +62,8 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"1 > 1", false},
 		{"1 == 1", true},
 		{"1 != 1", false},
+		{"0b0110 & 4 == 4", true},
+		{"0b0010 & 4 == 4", false},
 		{"1 == 2", false},
 		{"1 != 2", true},
 		{"true == true", true},
@@ -156,6 +174,90 @@ func TestLetStatement(t *testing.T) {
 	}
 }
 
+func TestConstStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"const a = 5; a;", 5},
+		{"const a = 5; let f = fn() { let a = 6; a }; f();", 6},
+		{"const a = 5; let f = fn(a) { a }; f(7);", 7},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	evaluated := testEval("const a = 5; let a = 6; a;")
+	errObj, ok := evaluated.(*object.Error)
+	require.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated)
+
+	assert.Equal(t, "can not reassign constant `a`", errObj.Message)
+}
+
+func TestFreeze(t *testing.T) {
+	evaluated := testEval("let x = [1, [2, 3]]; freeze(x);")
+	arr, ok := evaluated.(*object.Array)
+	require.Truef(t, ok, "object is not an array. got %T (%+v)", evaluated, evaluated)
+
+	assert.True(t, arr.Frozen)
+	assert.True(t, arr.Elements[1].(*object.Array).Frozen)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = freeze([1, 2]); push(x, 3)", "ERROR: push: can not change [1, 2], it is frozen"},
+		{"let x = freeze([1, [2]]); push(x[1], 3)", "ERROR: push: can not change [2], it is frozen"},
+		{"let x = freeze([1, 2]); pop(x)", "ERROR: pop: can not change [1, 2], it is frozen"},
+		{`let x = freeze({"a": {"b": 1}}); put(x["a"], "c", 2)`, `ERROR: put: can not change {"b": 1}, it is frozen`},
+		{`let x = freeze({"a": 1}); delete(x, "a")`, `ERROR: delete: can not change {"a": 1}, it is frozen`},
+		{"let x = freeze(#{1, 2}); add(x, 3)", "ERROR: add: can not change #{1, 2}, it is frozen"},
+		{"let x = freeze(#{1, 2}); delete(x, 1)", "ERROR: delete: can not change #{1, 2}, it is frozen"},
+		{"let x = freeze([#{1}]); add(x[0], 2)", "ERROR: add: can not change #{1}, it is frozen"},
+		{"let x = freeze([1, 2]); push(tail(x), 3)", "ERROR: push: can not change [2], it is frozen"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestMutation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = [1, 2]; push(x, 3); x", "[1, 2, 3]"},
+		{"let x = [1, 2]; push(x, 3, 4)", "[1, 2, 3, 4]"},
+		{"let x = [1, 2]; pop(x); x", "[1]"},
+		{`let x = {"a": 1}; put(x, "b", 2); x`, `{"a": 1, "b": 2}`},
+		{`let x = {"a": 1}; delete(x, "a")`, "true"},
+		{"let x = #{1}; add(x, 2); x", "#{1, 2}"},
+		{"let x = #{1}; delete(x, 2)", "false"},
+		{"let x = [1, 2, 3]; let y = tail(x); pop(y); push(y, 9); x", "[1, 2, 3]"},
+		{"pop([])", "ERROR: pop: can not pop from an empty array"},
+		{"push([1])", "ERROR: push: expected at least 2 arguments. given 1"},
+		{"put({}, [1], 2)", "ERROR: put: can not use `ARRAY` as a hash key"},
+		{"delete([1], 1)", "ERROR: delete: argument 1 must be `HASH` or `SET`, got `ARRAY`"},
+		{"let a = [1]; push(a, a)", "[1, [...]]"},
+		{"let a = []; push(a, (1, a))", "[(1, [...])]"},
+		{`let h = {}; put(h, "self", h)`, `{"self": {...}}`},
+		{`let h = {}; put(h, "a", [h])`, `{"a": [{...}]}`},
+		{`let a = ["x"]; push(a, a); format("%v", a)`, `"[x, [...]]"`},
+		{"let a = [1]; push(a, a); json.stringify(a)", "ERROR: json.stringify: can not convert a value that contains itself to JSON"},
+		{"let a = [1]; push(a, a); let b = [1]; push(b, b); sort([a, b])", "ERROR: can not compare values that contain themselves"},
+		{"let a = [1]; push(a, a); let b = [1]; push(b, b); a == b", "true"},
+		{"let a = [1]; push(a, a); len(sort([a, a]))", "2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
 func TestLetString(t *testing.T) {
 	input := `let x = "hello"; x;`
 	evaluated := testEval(input)
@@ -216,6 +318,251 @@ addTwo(2);
 	testIntegerObject(t, testEval(input), 4)
 }
 
+func TestPipeOperator(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let double = fn(x) { x * 2 }; 5 |> double;", 10},
+		{"let add = fn(x, y) { x + y }; 5 |> add(3);", 8},
+		{"let add = fn(x, y) { x + y }; let double = fn(x) { x * 2 }; 1 |> add(2) |> double;", 6},
+		{"[1, 2, 3] |> len", 3},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"let add = (x, y) => x + y; add(2, 3);", 5},
+		{"let double = x => x * 2; double(4);", 8},
+		{"let five = () => 5; five();", 5},
+		{"4 |> x => x * x", 16},
+		{"let adder = x => y => x + y; let addOne = adder(1); let addTen = adder(10); addOne(1) + addTen(1);", 13},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestNullSafety(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"null", nil},
+		{"null ?? 5", 5},
+		{"3 ?? 5", 3},
+		{"if (false) { 1 } ?? 2", 2},
+		{"let f = fn() { let x = 1; }; f() ?? 4", 4},
+		{"null ?? null", nil},
+		{"let x = null; x?[0]", nil},
+		{"let x = [7]; x?[0]", 7},
+		{"let x = null; x?.field", nil},
+		{"let x = null; x?.field ?? 9", 9},
+		{"let x = null; x == null", true},
+		{"let x = 1; x == null", false},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestEnums(t *testing.T) {
+	shapes := `
+enum Shape { Circle(r), Rect(w, h), Empty }
+
+let area = fn(shape) {
+  match(shape,
+    "Circle", fn(r) { 3 * r * r },
+    "Rect", fn(w, h) { w * h },
+    "Empty", fn() { 0 })
+};
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"area(Shape.Circle(2))", 12},
+		{"area(Shape.Rect(2, 5))", 10},
+		{"area(Shape.Empty)", 0},
+		{"Shape.Rect(2, 5).h", 5},
+		{"Shape.Circle(2) == Shape.Circle(2)", true},
+		{"Shape.Circle(2) == Shape.Circle(3)", false},
+		{"Shape.Circle(2) != Shape.Rect(2, 2)", true},
+		{"Shape.Empty == Shape.Empty", true},
+		{`enum Label { Text(s) } Label.Text("a") == Label.Text("a")`, true},
+		{`match(Shape.Rect(1, 2), "Circle", fn(r) { r }, "_", fn(s) { 7 })`, 7},
+		{`match(Shape.Circle(4), "Circle", fn(r) { r }, "_", fn(s) { 7 })`, 4},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(shapes + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+
+	assert.Equal(t, "Shape.Rect(1, 2)", testEval(shapes+"Shape.Rect(1, 2)").Inspect())
+	assert.Equal(t, "Shape.Empty", testEval(shapes+"Shape.Empty").Inspect())
+	assert.Equal(t, "Shape.Circle(r)", testEval(shapes+"Shape.Circle").Inspect())
+	assert.Equal(t, "enum Shape { Circle(r), Rect(w, h), Empty }", testEval(shapes+"Shape").Inspect())
+}
+
+func TestEnumErrors(t *testing.T) {
+	shapes := "enum Shape { Circle(r), Rect(w, h), Empty }\n"
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match(Shape.Empty, "Circle", fn(r) { r }, "Rect", fn(w, h) { w })`, "match: unhandled variants of `Shape`: Empty"},
+		{`match(Shape.Empty, "Square", fn(r) { r })`, "match: `Shape` has no variant `Square`"},
+		{`match(5, "Circle", fn(r) { r })`, "match: can only match on enum variants, got `INTEGER`"},
+		{`Shape.Rect(1)`, "incorrect number of arguments: need 2, got 1"},
+		{`Shape.Square`, "member expression: `ENUM` has no member `Square` (line 2)"},
+		{`Shape.Circle(1).w`, "member expression: `VARIANT` has no member `w` (line 2)"},
+		{`Shape.Circle(1) + Shape.Circle(1)`, "unknown operator: binary + not defined for `VARIANT` and `VARIANT`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(shapes + tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestDeepEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{`[[1, "a"], []] == [[1, "a"], []]`, true},
+		{`[[1, "a"], []] == [[1, "b"], []]`, false},
+		{"[1, (2, [3])] == [1, (2, [3])]", true},
+		{"#{(1, 2), 3} == #{3, (1, 2)}", true},
+		{"1 == 1.0", true},
+		{"[1] == [1.0]", true},
+		{"[(1, [2.0])] == [(1.0, [2])]", true},
+		{"[1] == [1.5]", false},
+		{"#{1} == #{1.0}", true},
+		{"len(#{1, 1.0}) == 1", true},
+		{"len(#{1, 1.5}) == 2", true},
+		{`let h = {1: "a"}; h[1.0] == "a"`, true},
+		{"9007199254740993 == 9007199254740992.0", false},
+		{"9007199254740993 != 9007199254740992.0", true},
+		{"[null] == [null]", true},
+		{`[1] == ["1"]`, false},
+		{"[1] == (1,)", false},
+		{"let f = fn(x) { x }; [f] == [f]", true},
+		{"[fn(x) { x }] == [fn(x) { x }]", false},
+		{"same([1], [1])", false},
+		{"let a = [1]; same(a, a)", true},
+		{"let a = [1]; same(a, freeze(a))", true},
+		{"same(1, 1)", true},
+		{`same("a", "a")`, true},
+		{"same(null, null)", true},
+		{"same(1, 2)", false},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestOperatorOverloading(t *testing.T) {
+	money := `
+enum Money { Cents(n) }
+enum Grid { Of(rows) }
+
+overload(Money, "+", fn(a, b) { Money.Cents(a.n + b.n) });
+overload(Money, "*", fn(a, k) { Money.Cents(a.n * k) });
+overload(Money, "<", fn(a, b) { a.n < b.n });
+overload(Money, "==", fn(a, b) { a.n / 100 == b.n / 100 });
+overload(Grid, "[]", fn(g, i) { g.rows[i] });
+`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"(Money.Cents(150) + Money.Cents(250)).n", 400},
+		{"(Money.Cents(150) * 3).n", 450},
+		{"Money.Cents(1) < Money.Cents(2)", true},
+		{"Money.Cents(101) == Money.Cents(199)", true},
+		{"Money.Cents(101) != Money.Cents(199)", false},
+		{"Money.Cents(101) != Money.Cents(200)", true},
+		{"Grid.Of([[1, 2], [3, 4]])[1][0]", 3},
+		{"(Money.Cents(1),) == (Money.Cents(50),)", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(money + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestOperatorOverloadingErrors(t *testing.T) {
+	money := "enum Money { Cents(n) }\n"
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`overload(Money, "&&", fn(a, b) { a })`, "overload: can not overload `&&`"},
+		{`overload(5, "+", fn(a, b) { a })`, "overload: can only overload operators of enums, got `INTEGER`"},
+		{`overload(Money, "+", 5)`, "overload: handler must be a function, got `INTEGER`"},
+		{`overload(Money, "+")`, "overload: expected exactly 3 arguments. given 2"},
+		{`overload(Money, "+", fn(a, b) { a.n + b.n }); Money.Cents(1) + 2`, "member expression: `INTEGER` has no member `n` (line 2)"},
+		{`overload(Money, "+", fn(a, b) { a }); Money.Cents(1) - Money.Cents(1)`, "unknown operator: binary - not defined for `VARIANT` and `VARIANT`"},
+		{`Money.Cents(1)[0]`, "index expression: can not take index of type `VARIANT` with `INTEGER`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(money + tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
 func TestErrors(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -260,6 +607,38 @@ if (10 > 1) {
 			"foobar",
 			"unknown reference on line 1: foobar",
 		},
+		{
+			"#{[1]}",
+			"set: can not use `ARRAY` as a set element",
+		},
+		{
+			"union(#{1}, [1])",
+			"union: can only combine sets, got `ARRAY`",
+		},
+		{
+			"(1, 2)[2]",
+			"index expression: index out of tuple bounds",
+		},
+		{
+			"(1, 2) + (3, 4)",
+			"unknown operator: binary + not defined for `TUPLE` and `TUPLE`",
+		},
+		{
+			"1 << -1",
+			"negative shift count: -1",
+		},
+		{
+			"~true",
+			"unknown operator: unary ~ not defined for `BOOLEAN`",
+		},
+		{
+			"let x = null; x.field",
+			"member expression: `NULL` has no member `field` (line 1)",
+		},
+		{
+			"let x = null; x[0]",
+			"index expression: can not take index of type `NULL` with `INTEGER`",
+		},
 	}
 
 	for _, tt := range tests {
@@ -283,6 +662,1029 @@ func TestArrayIndex(t *testing.T) {
 
 }
 
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"#{1, 2, 2, 3}", "#{1, 2, 3}"},
+		{`#{"a", (1, 2), (1, 2), true}`, `#{"a", (1, 2), true}`},
+		{"union(#{1, 2}, #{2, 3})", "#{1, 2, 3}"},
+		{"intersect(#{1, 2, 3}, #{3, 2, 4})", "#{2, 3}"},
+		{"difference(#{1, 2, 3}, #{2})", "#{1, 3}"},
+		{"contains(#{1, 2}, 2)", true},
+		{"contains(#{(1, 2)}, (1, 2))", true},
+		{"contains(#{1, 2}, 3)", false},
+		{"#{1, 2} == #{2, 1}", true},
+		{"#{1, 2} == #{1, 2, 3}", false},
+		{"#{1, 2} != #{1}", true},
+		{"len(#{1, 1, 1})", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"(1, 2)[1]", 2},
+		{"let t = (1, (2, 3)); t[1][0]", 2},
+		{"len((1, 2, 3))", 3},
+		{"(1, 2) == (1, 2)", true},
+		{`(1, "a") == (1, "a")`, true},
+		{"(1, 2) == (2, 1)", false},
+		{"(1, 2) == (1, 2, 3)", false},
+		{"(1, 2) != (1, 3)", true},
+		{"(1,)", "(1,)"},
+		{"()", "()"},
+		{`(1, "a", [true])`, `(1, "a", [true])`},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"[x * 2 for x in [1, 2, 3]]", "[2, 4, 6]"},
+		{"[x for x in [-1, 2, -3, 4] if x > 0]", "[2, 4]"},
+		{"#{x % 3 for x in [1, 2, 3, 4, 5, 6]}", "#{1, 2, 0}"},
+		{`[c + c for c in "héllo"]`, `["hh", "éé", "ll", "ll", "oo"]`},
+		{"[x for x in (1, 2)]", "[1, 2]"},
+		{"[x for x in #{3, 3, 4}]", "[3, 4]"},
+		{"[x for x in []]", "[]"},
+		{"let fs = [fn() { x } for x in [1, 2, 3]]; fs[0]() + fs[2]()", 4},
+		{"let fs = [() => x for x in [1, 2, 3]]; fs[0]()", 1},
+		{"let fs = [() => x for x in [1, 2, 3]]; [f() for f in fs]", "[1, 2, 3]"},
+		{"let fs = [(y: int) => x + y for x in [10, 20]]; fs[1](2)", 22},
+		{"let x = 10; let ys = [x for x in [1, 2]]; x", 10},
+		{"let f = fn(xs) { [x * x for x in xs] }; f([3])[0]", 9},
+		{"{x: x * x for x in [1, 2, 3]}", "{1: 1, 2: 4, 3: 9}"},
+		{`{c: 1 for c in "ab"}`, `{"a": 1, "b": 1}`},
+		{"{x % 2: x for x in [1, 2, 3, 4, 5] if x > 1}", "{0: 4, 1: 5}"},
+		{"{x: x for x in []}", "{}"},
+		{"let x = 10; let h = {x: x for x in [1]}; x", 10},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+
+	evaluated := testEval("[x for x in 5]")
+	errObj, ok := evaluated.(*object.Error)
+	require.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, "comprehension: can not iterate over `INTEGER` (line 1)", errObj.Message)
+
+	evaluated = testEval("{[x]: x for x in [1]}")
+	errObj, ok = evaluated.(*object.Error)
+	require.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, "hash: can not use `ARRAY` as a hash key", errObj.Message)
+
+	evaluated = testEval("[y for x in [1]]; x")
+	errObj, ok = evaluated.(*object.Error)
+	require.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, "unknown reference on line 1: y", errObj.Message)
+}
+
+func TestStringPredefs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`split("a,b,,c", ",")`, `["a", "b", "", "c"]`},
+		{`split("héllo", "")`, `["h", "é", "l", "l", "o"]`},
+		{`join(["a", "b", "c"], ", ")`, `"a, b, c"`},
+		{`join([], "-")`, `""`},
+		{"trim(\" \n hi  \")", `"hi"`},
+		{`trimLeft("  hi  ")`, `"hi  "`},
+		{`trimRight("  hi  ")`, `"  hi"`},
+		{`upper("héllo")`, `"HÉLLO"`},
+		{`lower("ÉCOLE")`, `"école"`},
+		{`replace("a-b-c", "-", "+")`, `"a+b+c"`},
+		{`contains("hummus", "mm")`, true},
+		{`contains("hummus", "x")`, false},
+		{`startsWith("hummus", "hum")`, true},
+		{`endsWith("hummus", "hum")`, false},
+		{`indexOf("héllo", "l")`, 2},
+		{`indexOf("hello", "z")`, -1},
+		{`let s = "héllo"; s[indexOf(s, "l")]`, `"l"`},
+		{`"héllo"[1]`, `"é"`},
+		{`len("héllo")`, 5},
+		{`head("éa")`, `"é"`},
+		{`tail("éa")`, `"a"`},
+		{`len(padLeft("é", 3))`, 3},
+		{`repeat("ab", 3)`, `"ababab"`},
+		{`repeat("ab", 0)`, `""`},
+		{`padLeft("7", 3)`, `"  7"`},
+		{`padLeft("7", 3, "0")`, `"007"`},
+		{`padRight("é", 4, "ab")`, `"éaba"`},
+		{`padRight("hummus", 3)`, `"hummus"`},
+		{`chars("día")`, `["d", "í", "a"]`},
+		{`reverse("héllo")`, `"olléh"`},
+		{`len(chars("día"))`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestStringPredefErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a")`, "split: expected exactly 2 arguments. given 1"},
+		{`split("a", 1)`, "split: argument 2 must be `STRING`, got `INTEGER`"},
+		{`upper(1)`, "upper: argument 1 must be `STRING`, got `INTEGER`"},
+		{`trim("a", "b")`, "trim: expected exactly 1 argument. given 2"},
+		{`join(["a", 1], "")`, "join: can only join strings, got `INTEGER`"},
+		{`join("a", "")`, "join: argument 1 must be `ARRAY`, got `STRING`"},
+		{`repeat("a", -1)`, "repeat: count must not be negative. given -1"},
+		{`repeat("ab", 9223372036854775807)`, "repeat: result would be longer than 16777216 bytes"},
+		{`padLeft("a", 9223372036854775807)`, "padLeft: width must not be more than 16777216. given 9223372036854775807"},
+		{`"héllo"[5]`, "index expression: index out of string bounds"},
+		{`padLeft("a")`, "padLeft: expected 2 or 3 arguments. given 1"},
+		{`padLeft("a", 3, "")`, "padLeft: padding must not be empty"},
+		{`contains("a", 1)`, "contains: can only search for a `STRING` in a string, got `INTEGER`"},
+		{`reverse(1)`, "reverse: can not reverse `INTEGER`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestArrayPredefs(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"map([1, 2, 3], fn(x) { x * 2 })", "[2, 4, 6]"},
+		{"[1, 2, 3] |> map(x => x + 1)", "[2, 3, 4]"},
+		{"map([], fn(x) { x })", "[]"},
+		{"map([1], fn(x) { let y = x; })", "[null]"},
+		{"filter([1, 2, 3, 4], fn(x) { x % 2 == 0 })", "[2, 4]"},
+		{"reduce([1, 2, 3, 4], 0, fn(acc, x) { acc + x })", 10},
+		{`reduce([], "start", fn(acc, x) { acc })`, `"start"`},
+		{"each([1, 2], fn(x) { x })", nil},
+		{"find([1, 2, 3], fn(x) { x > 1 })", 2},
+		{"find([1, 2, 3], fn(x) { x > 5 })", nil},
+		{"any([1, 2, 3], fn(x) { x > 2 })", true},
+		{"any([], fn(x) { true })", false},
+		{"all([1, 2, 3], fn(x) { x > 0 })", true},
+		{"all([1, 2, 3], fn(x) { x > 1 })", false},
+		{`zip([1, 2, 3], ["a", "b"])`, `[(1, "a"), (2, "b")]`},
+		{"flatten([[1], 2, [3, [4]]])", "[1, 2, 3, [4]]"},
+		{"flatMap([1, 2], fn(x) { [x, x] })", "[1, 1, 2, 2]"},
+		{"groupBy([1, 2, 3, 4, 5], fn(x) { x % 2 })", "{1: [1, 3, 5], 0: [2, 4]}"},
+		{`groupBy(["ab", "c", "de"], fn(s) { len(s) })[2]`, `["ab", "de"]`},
+		{"groupBy([], fn(x) { x })", "{}"},
+		{"range(9223372036854775805, 9223372036854775807, 5)", "[9223372036854775805]"},
+		{"range(9223372036854775806, 9223372036854775807)", "[9223372036854775806]"},
+		{"range(-9223372036854775806, -9223372036854775807 - 1, -3)", "[-9223372036854775806]"},
+		{"uniq([1, 2, 1, 3, 2])", "[1, 2, 3]"},
+		{"uniq([[1], [2], [1]])", "[[1], [2]]"},
+		{"take([1, 2, 3], 2)", "[1, 2]"},
+		{"take([1, 2, 3], 5)", "[1, 2, 3]"},
+		{"drop([1, 2, 3], 1)", "[2, 3]"},
+		{"drop([1, 2, 3], 5)", "[]"},
+		{"reverse([1, 2, 3])", "[3, 2, 1]"},
+		{"concat([1], [], [2, 3])", "[1, 2, 3]"},
+		{"range(3)", "[0, 1, 2]"},
+		{"range(2, 5)", "[2, 3, 4]"},
+		{"range(5, 0, -2)", "[5, 3, 1]"},
+		{"range(0, 4194304, 2097152)", "[0, 2097152]"},
+		{"len(range(4194304))", 4194304},
+		{"range(0, -9223372036854775807 - 1, -9223372036854775807 - 1)", "[0]"},
+		{"sum(range(101))", 5050},
+		{"sum([])", 0},
+		{"sum([1, 2.5, 3])", "6.5"},
+		{"sum([0.5, 0.5])", "1.0"},
+		{"map((1, 2), fn(x) { x })", "[1, 2]"},
+		{"len(filter(range(100000), fn(x) { x % 2 == 0 }))", 50000},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestArrayPredefErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"map([1])", "map: expected exactly 2 arguments. given 1"},
+		{"map(1, fn(x) { x })", "map: can not iterate over `INTEGER`"},
+		{"map([1], fn(x, y) { x })", "incorrect number of arguments: need 2, got 1"},
+		{"map([1, 2], fn(x) { x + true })", "type mismatch: can not + `INTEGER` and `BOOLEAN`"},
+		{"reduce([1], fn(a, x) { a })", "reduce: expected exactly 3 arguments. given 2"},
+		{"flatMap([1], fn(x) { x })", "flatMap: function must return `ARRAY`, got `INTEGER`"},
+		{"groupBy([1], fn(x) { [x] })", "groupBy: can not group by `ARRAY`"},
+		{"take([1], -1)", "take: count must not be negative. given -1"},
+		{`drop([1], "a")`, "drop: argument 2 must be `INTEGER`, got `STRING`"},
+		{"concat([1], 2)", "concat: argument 2 must be `ARRAY`, got `INTEGER`"},
+		{"range(1, 2, 0)", "range: step must not be 0"},
+		{"range()", "range: expected 1 to 3 arguments. given 0"},
+		{"range(10000000000000)", "range: can not make more than 4194304 numbers. given a range of 10000000000000"},
+		{"range(-9223372036854775807 - 1, 9223372036854775807)", "range: can not make more than 4194304 numbers. given a range of 18446744073709551615"},
+		{`sum([1, "a"])`, "sum: can only add up numbers, got `STRING`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestSorting(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sort([3, 1, 2])", "[1, 2, 3]"},
+		{`sort(["pear", "apple", "fig"])`, `["apple", "fig", "pear"]`},
+		{`sort([2, "a", null, true, 1, false])`, `[null, false, true, 1, 2, "a"]`},
+		{"sort([(2, 1), (1, 2), (1, 1)])", "[(1, 1), (1, 2), (2, 1)]"},
+		{"sort([[1, 2], [1], []])", "[[], [1], [1, 2]]"},
+		{"sort(#{3, 1, 2})", "[1, 2, 3]"},
+		{"sort([])", "[]"},
+		{`sortBy(["ccc", "a", "bb", "d"], fn(s) { len(s) })`, `["a", "d", "bb", "ccc"]`},
+		{"sortBy([(1, 2), (0, 2), (5, 1)], fn(p) { p[1] })", "[(5, 1), (1, 2), (0, 2)]"},
+		{"sortWith([1, 3, 2], fn(a, b) { b - a })", "[3, 2, 1]"},
+		{"sortWith([(1, 2), (0, 1), (1, 1)], fn(a, b) { a[0] - b[0] })", "[(0, 1), (1, 2), (1, 1)]"},
+		{"let xs = freeze([2, 1]); sort(xs); xs", "[2, 1]"},
+		{`enum Size { Of(n) }
+overload(Size, "<", fn(a, b) { a.n < b.n });
+sort([Size.Of(3), Size.Of(1), Size.Of(2)])`, "[Size.Of(1), Size.Of(2), Size.Of(3)]"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, testEval(tt.input).Inspect())
+	}
+}
+
+func TestSortingErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"sort([1], [2])", "sort: expected exactly 1 argument. given 2"},
+		{"sort(1)", "sort: can not iterate over `INTEGER`"},
+		{"sort([fn(x) { x }, fn(x) { x }])", "can not compare `FUNCTION` and `FUNCTION`"},
+		{"sortBy([1, 2], fn(x) { x + true })", "type mismatch: can not + `INTEGER` and `BOOLEAN`"},
+		{"sortWith([1, 2], fn(a, b) { a + true })", "type mismatch: can not + `INTEGER` and `BOOLEAN`"},
+		{"sortWith([1, 2], fn(a, b) { true })", "sortWith: comparator must return `INTEGER`, got `BOOLEAN`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestFloats(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1.5", "1.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"7 / 2.0", "3.5"},
+		{"-2.5", "-2.5"},
+		{"5.5 % 2", "1.5"},
+		{"0.1 + 0.2 > 0.3", true},
+		{"1 == 1.0", true},
+		{"[1.5] == [1.5]", true},
+		{"1.5 < 2", true},
+		{"sort([2, 1.5, 1])", "[1, 1.5, 2]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMathModule(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"math.abs(-5)", 5},
+		{"math.abs(-2.5)", "2.5"},
+		{"math.min(3, 1, 2)", 1},
+		{"math.max([3, 7.5, 2])", "7.5"},
+		{"math.pow(2, 10)", 1024},
+		{"math.pow(-1, 1001)", -1},
+		{"math.pow(3, 0)", 1},
+		{"math.pow(2, 0.5)", "1.4142135623730951"},
+		{"math.sqrt(16)", "4.0"},
+		{"math.floor(2.7)", 2},
+		{"math.ceil(2.1)", 3},
+		{"math.round(-2.5)", -3},
+		{"math.floor(7)", 7},
+		{"math.log(math.E)", "1.0"},
+		{"math.exp(0)", "1.0"},
+		{"math.sin(0)", "0.0"},
+		{"math.round(math.cos(math.PI))", -1},
+		{"math.round(math.atan2(1, 1) * 4 * 1000)", 3142},
+		{"math.gcd(12, -18)", 6},
+		{"math.lcm(4, 6)", 12},
+		{"math.lcm(0, 6)", 0},
+		{"math.clamp(15, 0, 10)", 10},
+		{"math.clamp(-1, 0, 10)", 0},
+		{"math.clamp(5, 0, 10)", 5},
+		{"math.MAX_INT", 9223372036854775807},
+		{"let m = math; m.abs(-1)", 1},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestMathModuleErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"math.sqrt(-1)", "math.sqrt: argument must not be negative, got -1"},
+		{"math.log(0)", "math.log: argument must be positive, got 0"},
+		{"math.asin(2)", "math.asin: argument must be between -1 and 1, got 2"},
+		{`math.sqrt("a")`, "math.sqrt: argument 1 must be a number, got `STRING`"},
+		{"math.sqrt(1, 2)", "math.sqrt: expected exactly 1 argument. given 2"},
+		{"math.pow(2, 64)", "math.pow: integer overflow"},
+		{"math.pow(2, -1)", "math.pow: integer exponent must not be negative, got -1"},
+		{"math.pow(-8, 0.5)", "math.pow: -8 to the power of 0.5 is not a number"},
+		{"math.abs(math.MIN_INT)", "math.abs: integer overflow"},
+		{"math.gcd(1.5, 2)", "math.gcd: argument 1 must be `INTEGER`, got `FLOAT`"},
+		{"math.lcm(math.MAX_INT, 2)", "math.lcm: integer overflow"},
+		{"math.floor(1.0 / 0)", "math.floor: +Inf is out of integer range"},
+		{"math.min()", "math.min: expected at least 1 number"},
+		{`math.max(1, "a")`, "math.max: can only compare numbers, got `STRING`"},
+		{"math.clamp(1, 10, 0)", "math.clamp: lower bound 10 is greater than upper bound 0"},
+		{"math.tau", "member expression: `MODULE` has no member `tau` (line 1)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestRegex(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`/b+/`, "/b+/"},
+		{`regex.compile("B+", "i")`, "/B+/i"},
+		{`regex.match(/^\d+$/, "123")`, "true"},
+		{`regex.match("^\d+$", "12a")`, "false"},
+		{`regex.match(/abc/i, "xABCx")`, "true"},
+		{`regex.find(/(\w+)@(\w+)(\.org)?/, "mail bob@example now")`, `["bob@example", "bob", "example", null]`},
+		{`regex.find(/x/, "abc")`, "null"},
+		{`regex.findAll(/(\d)(\w)/, "1a 2b 3")`, `[["1a", "1", "a"], ["2b", "2", "b"]]`},
+		{`regex.findAll(/x/, "abc")`, "[]"},
+		{`regex.replace(/(\w+)@(\w+)/, "bob@example", "$2 at ${1}")`, `"example at bob"`},
+		{`regex.replace(/\d+/, "a1b22", fn(groups) { repeat("#", len(groups[0])) })`, `"a#b##"`},
+		{`regex.replace("o", "foo", fn(groups) { upper(groups[0]) })`, `"fOO"`},
+		{`regex.split(/\s*,\s*/, "a , b,c")`, `["a", "b", "c"]`},
+		{`regex.split(/a\/b/, "1a/b2")`, `["1", "2"]`},
+		{`let half = 10 / 2 / 5; half`, "1"},
+		{`let re = /^a.c$/s; regex.match(re, "a` + "\n" + `c")`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`regex.compile("a(")`, "regex.compile: error parsing regexp: missing closing ): `a(`"},
+		{`regex.compile("a", "g")`, "regex.compile: unknown regex flag 'g'"},
+		{`regex.match("a(", "a")`, "regex.match: error parsing regexp: missing closing ): `a(`"},
+		{`regex.match(1, "a")`, "regex.match: argument 1 must be `REGEX` or `STRING`, got `INTEGER`"},
+		{`regex.find(/a/, 1)`, "regex.find: argument 2 must be `STRING`, got `INTEGER`"},
+		{`regex.split(/a/)`, "regex.split: expected exactly 2 arguments. given 1"},
+		{`regex.replace(/a/, "a", 1)`, "regex.replace: argument 3 must be `STRING` or `FUNCTION`, got `INTEGER`"},
+		{`regex.replace(/a/, "a", fn(groups) { 1 })`, "regex.replace: replacement function must return `STRING`, got `INTEGER`"},
+	}
+
+	for _, tt := range errors {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestRegexCache(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 1; n < 3*maxCompiledPatterns; n++ {
+				evaluated := testEval(`regex.match("^` + strings.Repeat("a", n) + `$", "aaa")`)
+				assert.Equal(t, n == 3, evaluated == True)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, compiledPatterns.entries, maxCompiledPatterns)
+	assert.Equal(t, maxCompiledPatterns, compiledPatterns.recent.Len())
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`time.date(2024, 2, 29, 13, 30, 0)`, "time(2024-02-29T13:30:00Z)"},
+		{`time.date(2024, 1, 2, "America/New_York")`, "time(2024-01-02T00:00:00-05:00)"},
+		{`time.parse(time.DATE, "2024-03-01") - time.parse(time.DATE, "2024-02-28")`, "duration(48h0m0s)"},
+		{`time.parse(time.DATETIME, "2024-07-01 09:00:00", "Europe/Paris")`, "time(2024-07-01T09:00:00+02:00)"},
+		{`time.parse(time.RFC3339, "2024-07-01T09:00:00Z") + time.duration("1h30m")`, "time(2024-07-01T10:30:00Z)"},
+		{`time.date(2024, 1, 1) - 24 * time.HOUR`, "time(2023-12-31T00:00:00Z)"},
+		{`time.format(time.inZone(time.date(2024, 1, 1, 12, 0, 0), "Asia/Tokyo"), "15:04 MST")`, `"21:00 JST"`},
+		{`time.zone(time.inZone(time.date(2024, 1, 1), "Asia/Tokyo"))`, `"Asia/Tokyo"`},
+		{`time.date(2024, 1, 1) == time.inZone(time.date(2024, 1, 1), "Asia/Tokyo")`, "true"},
+		{`time.date(2024, 1, 1) < time.date(2024, 1, 2)`, "true"},
+		{`time.date(2024, 1, 1) > time.date(2024, 1, 2)`, "false"},
+		{`time.date(2024, 1, 1) != time.date(2024, 1, 2)`, "true"},
+		{`time.HOUR / time.MINUTE`, "60"},
+		{`time.HOUR / 3`, "duration(20m0s)"},
+		{`time.HOUR * 1.5`, "duration(1h30m0s)"},
+		{`-time.duration("90s") + time.MINUTE`, "duration(-30s)"},
+		{`time.duration("2h") % time.duration("45m")`, "duration(30m0s)"},
+		{`time.seconds(time.MILLISECOND * 1500)`, "1.5"},
+		{`time.MINUTE > time.SECOND`, "true"},
+		{`time.unix(time.fromUnix(86400))`, "86400"},
+		{`time.fromUnix(86400)`, "time(1970-01-02T00:00:00Z)"},
+		{`let p = time.parts(time.date(2024, 2, 29, 13, 30, 5)); (p["year"], p["month"], p["day"], p["hour"], p["weekday"], p["yearDay"])`, `(2024, 2, 29, 13, "Thursday", 60)`},
+		{`sort([time.HOUR, time.SECOND, time.MINUTE])`, "[duration(1s), duration(1m0s), duration(1h0m0s)]"},
+		{`#{time.date(2024, 1, 1), time.inZone(time.date(2024, 1, 1), "Asia/Tokyo")}`, "#{time(2024-01-01T00:00:00Z)}"},
+		{`time.now() - time.now() < time.SECOND`, "true"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`time.date(2024, 1, 1, "Mars/Olympus")`, `time.date: unknown time zone "Mars/Olympus"`},
+		{`time.date(2024, 1)`, "time.date: expected between 3 and 7 arguments. given 2"},
+		{`time.date(2024, "1", 1)`, "time.date: argument 2 must be `INTEGER`, got `STRING`"},
+		{`time.parse(time.DATE, "01/02/2024")`, `time.parse: parsing time "01/02/2024" as "2006-01-02": cannot parse "01/02/2024" as "2006"`},
+		{`time.duration("soon")`, `time.duration: time: invalid duration "soon"`},
+		{`time.format(1, time.DATE)`, "time.format: argument 1 must be `TIME`, got `INTEGER`"},
+		{`time.HOUR / 0`, "division by zero"},
+		{`time.date(2024, 1, 1) + time.date(2024, 1, 1)`, "unknown operator: binary + not defined for `TIME` and `TIME`"},
+		{`time.date(2024, 1, 1) * 2`, "type mismatch: can not * `TIME` and `INTEGER`"},
+		{`time.now(1)`, "time.now: expected exactly 0 arguments. given 1"},
+	}
+
+	for _, tt := range errors {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	run := func(env *object.Environment, input string) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+
+	sample := `[random.int(1, 6), random.int(math.MIN_INT, math.MAX_INT), random.float(), random.choice("abc"), random.shuffle([1, 2, 3, 4, 5])]`
+
+	first, second := object.NewEnvironment(), object.NewEnvironment()
+	InstallRandom(first, 42)
+	InstallRandom(second, 42)
+
+	a := run(first, sample).Inspect()
+	assert.Equal(t, a, run(second, sample).Inspect())
+	assert.NotEqual(t, a, run(first, sample).Inspect())
+
+	assert.Equal(t, "null", run(first, "random.seed(42)").Inspect())
+	assert.Equal(t, a, run(first, sample).Inspect())
+
+	env := object.NewEnvironment()
+	InstallRandom(env, 7)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let xs = map(range(100), fn(i) { random.int(-2, 2) }); (math.min(xs), math.max(xs))`, "(-2, 2)"},
+		{`random.int(5, 5)`, "5"},
+		{`let f = random.float(); (f > -0.1, f < 1)`, "(true, true)"},
+		{`random.choice((7,))`, "7"},
+		{`sort(random.shuffle(#{3, 1, 2}))`, "[1, 2, 3]"},
+		{`let xs = freeze([1, 2, 3]); random.shuffle(xs); xs`, "[1, 2, 3]"},
+		{`random.int(2, 1)`, "ERROR: random.int: lower bound 2 is greater than upper bound 1"},
+		{`random.choice([])`, "ERROR: random.choice: can not choose from an empty `ARRAY`"},
+		{`random.shuffle(1)`, "ERROR: random.shuffle: can not iterate over `INTEGER`"},
+		{`random.seed("x")`, "ERROR: random.seed: argument 1 must be `INTEGER`, got `STRING`"},
+		{`random.float(1)`, "ERROR: random.float: expected exactly 0 arguments. given 1"},
+	}
+
+	for _, tt := range tests {
+		assert.Equalf(t, tt.expected, run(env, tt.input).Inspect(), "input: %s", tt.input)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	env := object.NewEnvironment()
+	exited := []int{}
+	InstallProcess(env, []string{"-v", "input.txt"}, func(code int) {
+		exited = append(exited, code)
+	})
+
+	os.Setenv("HUMMUS_TEST_VALUE", "hello")
+	defer os.Unsetenv("HUMMUS_TEST_VALUE")
+	defer os.Unsetenv("HUMMUS_TEST_SET")
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`args`, `["-v", "input.txt"]`},
+		{`env("HUMMUS_TEST_VALUE")`, `"hello"`},
+		{`env("HUMMUS_TEST_MISSING")`, "null"},
+		{`setEnv("HUMMUS_TEST_SET", "1"); env("HUMMUS_TEST_SET")`, `"1"`},
+		{`exit(3)`, "null"},
+		{`exit()`, "null"},
+		{`exit(256)`, "ERROR: exit: status code must be between 0 and 255, got 256"},
+		{`exit("1")`, "ERROR: exit: argument 1 must be `INTEGER`, got `STRING`"},
+		{`env(1)`, "ERROR: env: argument 1 must be `STRING`, got `INTEGER`"},
+	}
+
+	for _, tt := range tests {
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+	}
+
+	assert.Equal(t, []int{3, 0}, exited)
+	assert.Equal(t, "1", os.Getenv("HUMMUS_TEST_SET"))
+}
+
+func TestInput(t *testing.T) {
+	env := object.NewEnvironment()
+	InstallInput(env, strings.NewReader("first\r\nsecond\nthird\nfourth\nfifth"))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`readLine()`, `"first"`},
+		{`readLine(stdin)`, `"second"`},
+		{`eachLine(fn(line) { if (line == "fifth") { error } else { line } })`, "ERROR: unknown reference on line 1: error"},
+		{`eachLine(fn(line) { line })`, "null"},
+		{`readLine()`, "null"},
+		{`readAll()`, `""`},
+		{`readLine(1)`, "ERROR: readLine: argument 1 must be `FILE`, got `INTEGER`"},
+		{`eachLine(stdin, fn(line) { len(1) })`, "null"},
+	}
+
+	for _, tt := range tests {
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+	}
+
+	env = object.NewEnvironment()
+	InstallInput(env, strings.NewReader("a\nb\nc\n"))
+
+	evaluated := Eval(parser.New(lexer.New(`readLine(); readAll()`)).ParseProgram(), env)
+	assert.Equal(t, "\"b\nc\n\"", evaluated.Inspect())
+
+	env = object.NewEnvironment()
+	InstallInput(env, strings.NewReader("a\nb\n"))
+
+	evaluated = Eval(parser.New(lexer.New(`eachLine(fn(line) { len(1) })`)).ParseProgram(), env)
+	assert.Equal(t, "ERROR: len: can only take length of strings, arrays, tuples, sets and hashes", evaluated.Inspect())
+
+	evaluated = testEval(`readLine()`)
+	assert.Equal(t, "ERROR: readLine: expected exactly 1 argument. given 0", evaluated.Inspect())
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`format("plain")`, `"plain"`},
+		{`format("%d%%", 50)`, `"50%"`},
+		{`format("[%5d|%-5d|%05d|%+d]", 42, 42, 42, 42)`, `"[   42|42   |00042|+42]"`},
+		{`format("%x %X %#x %o %b", 255, 255, 255, 8, 5)`, `"ff FF 0xff 10 101"`},
+		{`format("[%6s|%-6s|%.2s]", "ab", "ab", "abc")`, `"[    ab|ab    |ab]"`},
+		{`format("%q", "say hi")`, `""say hi""`},
+		{`format("%.2f %e %g", 3.14159, 1500, 0.5)`, `"3.14 1.500000e+03 0.5"`},
+		{`format("%v and %v", "text", [1, "a"])`, `"text and [1, a]"`},
+		{`format("%#v", [1, "a"])`, `"[1, "a"]"`},
+		{`format("%-4v|", true)`, `"true|"`},
+		{`printf("%s", "")`, "null"},
+		{`print()`, "null"},
+		{`format("%d")`, "ERROR: format: missing argument for %d"},
+		{`format("%d", 1, 2)`, "ERROR: format: 2 arguments given but only 1 used"},
+		{`format("%5d", "x")`, "ERROR: format: %5d needs `INTEGER`, got `STRING`"},
+		{`format("%s", 1)`, "ERROR: format: %s needs `STRING`, got `INTEGER`"},
+		{`format("%f", "1")`, "ERROR: format: %f needs a number, got `STRING`"},
+		{`format("%y", 1)`, "ERROR: format: unknown verb %y"},
+		{`format("100%")`, `ERROR: format: unfinished verb "%" at the end of the format`},
+		{`format(1)`, "ERROR: format: argument 1 must be `STRING`, got `INTEGER`"},
+		{`printf()`, "ERROR: printf: expected at least 1 argument. given 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+	}
+}
+
+func TestExec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hummus")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	env := object.NewEnvironment()
+	require.NoError(t, InstallExec(env, "echo", "cat", "sh", "tr", "sleep", "pwd"))
+	env.Set("dir", &object.String{Value: dir})
+
+	assert.Error(t, InstallExec(object.NewEnvironment(), "hummus-no-such-command"))
+	assert.Error(t, InstallExec(object.NewEnvironment(), "/bin/echo"))
+	assert.Error(t, InstallExec(object.NewEnvironment(), "./echo"))
+
+	// a script changing PATH still runs the echo that was allowed
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "echo"), []byte("#!/bin/sh\necho hijacked\n"), 0755))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`exec("echo", ["hello", "world"])`, `{"stdout": "hello world` + "\n" + `", "stderr": "", "code": 0}`},
+		{`exec("cat", [], {"stdin": "fed in"})["stdout"]`, `"fed in"`},
+		{`exec("sh", ["-c", "echo oops >&2; exit 3"])`, `{"stdout": "", "stderr": "oops` + "\n" + `", "code": 3}`},
+		{`exec("sh", ["-c", "printf $GREETING"], {"env": {"GREETING": "hi"}})["stdout"]`, `"hi"`},
+		{`exec("pwd", [], {"cwd": dir})["stdout"] == exec("sh", ["-c", "cd " + dir + " && pwd"])["stdout"]`, "true"},
+		{`exec("sleep", ["1"], {"timeout": time.MILLISECOND * 50})`, "ERROR: exec: timed out after 50ms"},
+		{`exec("sleep", ["0"], {"timeout": 5})["code"]`, "0"},
+		{`pipeline([("echo", ["b a c"]), ("tr", [" ", "-"]), ("tr", ["a-z", "A-Z"])])["stdout"]`, `"B-A-C` + "\n" + `"`},
+		{`pipeline([("cat", []), ("sh", ["-c", "cat; exit 2"])], {"stdin": "x"})`, `{"stdout": "x", "stderr": "", "code": 2, "codes": [0, 2]}`},
+		{`exec("rm", ["-rf", dir])`, "ERROR: exec: permission denied: rm is not an allowed command"},
+		{`pipeline([("echo", []), ("rm", [])])`, "ERROR: pipeline: permission denied: rm is not an allowed command"},
+		{`exec("sh", ["-c", "sleep 5 & echo started"])`, `{"stdout": "started` + "\n" + `", "stderr": "", "code": 0}`},
+		{`setEnv("PATH", dir); exec("echo", ["safe"])["stdout"]`, `"safe` + "\n" + `"`},
+		{`exec("/bin/echo")`, "ERROR: exec: permission denied: /bin/echo is not an allowed command"},
+		{`exec("echo", "a")`, "ERROR: exec: arguments of echo must be `ARRAY`, got `STRING`"},
+		{`exec("echo", [1])`, "ERROR: exec: arguments of echo must be strings, got `INTEGER`"},
+		{`exec("echo", [], {"shell": true})`, `ERROR: exec: unknown option "shell"`},
+		{`exec("echo", [], {"timeout": -1})`, "ERROR: exec: timeout must be positive, got -1"},
+		{`exec("echo", [], {"env": {"A": 1}})`, `ERROR: exec: env must map strings to strings, got "A": 1`},
+		{`exec()`, "ERROR: exec: expected between 1 and 3 arguments. given 0"},
+		{`pipeline([])`, "ERROR: pipeline: expected at least 1 command"},
+		{`pipeline(["echo"])`, `ERROR: pipeline: commands must be tuples of a name and arguments, got "echo"`},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		assert.Equalf(t, tt.expected, evaluated.Inspect(), "input: %s", tt.input)
+		assert.Truef(t, time.Since(start) < 3*time.Second, "input: %s took %s", tt.input, time.Since(start))
+	}
+
+	_, err = os.Stat(dir)
+	assert.NoError(t, err)
+}
+
+func TestFileAccess(t *testing.T) {
+	root, err := ioutil.TempDir("", "hummus")
+	require.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	outside, err := ioutil.TempDir("", "hummus-outside")
+	require.NoError(t, err)
+	defer os.RemoveAll(outside)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "link.txt")))
+
+	env := object.NewEnvironment()
+	require.NoError(t, InstallFileAccess(env, root))
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"writeFile(\"a.txt\", \"one\ntwo\n\")", nil},
+		{`readFile("a.txt")`, "\"one\ntwo\n\""},
+		{`appendFile("a.txt", "three")`, nil},
+		{`readLines("a.txt")`, `["one", "two", "three"]`},
+		{`exists("a.txt")`, true},
+		{`exists("missing.txt")`, false},
+		{`mkdir("sub/dir")`, nil},
+		{`listDir(".")`, `["a.txt", "escape", "link.txt", "sub"]`},
+		{`remove("sub/dir")`, nil},
+		{`remove("link.txt")`, nil},
+		{`exists("link.txt")`, false},
+		{`listDir("sub")`, "[]"},
+		{"let f = open(\"b.txt\", \"w\"); write(f, \"x\n\"); write(f, \"y\"); close(f)", nil},
+		{`let f = open("b.txt", "r"); [readLine(f), readLine(f), readLine(f)]`, `["x", "y", null]`},
+		{`readFile("sub/../a.txt")`, "\"one\ntwo\nthree\""},
+	}
+
+	for _, tt := range tests {
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		switch expected := tt.expected.(type) {
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`readFile("../x.txt")`, "readFile: access denied: ../x.txt is outside of the allowed directories"},
+		{`readFile("` + filepath.Join(outside, "secret.txt") + `")`, "readFile: access denied: " + filepath.Join(outside, "secret.txt") + " is outside of the allowed directories"},
+		{`readFile("escape/secret.txt")`, "readFile: access denied: escape/secret.txt is outside of the allowed directories"},
+		{`writeFile("escape/new.txt", "x")`, "writeFile: access denied: escape/new.txt is outside of the allowed directories"},
+		{`readFile("missing.txt")`, "readFile: open missing.txt: no such file or directory"},
+		{`readFile(1)`, "readFile: argument 1 must be `STRING`, got `INTEGER`"},
+		{`remove(".")`, "remove: access denied: can not remove ."},
+		{`remove("..")`, "remove: access denied: .. is outside of the allowed directories"},
+		{`remove("escape/secret.txt")`, "remove: access denied: escape/secret.txt is outside of the allowed directories"},
+		{`remove("missing.txt")`, "remove: lstat missing.txt: no such file or directory"},
+		{`open("a.txt", "x")`, `open: unknown mode "x", expected "r", "w" or "a"`},
+		{`let f = open("a.txt", "r"); write(f, "x")`, "write: file(a.txt) was not opened for writing"},
+		{`let f = open("a.txt", "r"); close(f); readLine(f)`, "readLine: file(a.txt) is closed"},
+	}
+
+	for _, tt := range errors {
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+
+	_, err = os.Stat(filepath.Join(outside, "secret.txt"))
+	assert.NoError(t, err, "removing a link should leave what it points to")
+
+	evaluated := testEval(`readFile("a.txt")`)
+	errObj, ok := evaluated.(*object.Error)
+	require.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated)
+	assert.Equal(t, "unknown reference on line 1: readFile", errObj.Message)
+
+	none := object.NewEnvironment()
+	require.NoError(t, InstallFileAccess(none))
+	for _, input := range []string{`readFile("a.txt")`, `writeFile("a.txt", "x")`, `remove("a.txt")`} {
+		evaluated := Eval(parser.New(lexer.New(input)).ParseProgram(), none)
+		errObj, ok := evaluated.(*object.Error)
+		if assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			assert.Contains(t, errObj.Message, "access denied: a.txt is outside of the allowed directories")
+		}
+	}
+}
+
+func TestHashes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`{"a": 1, "b": 2}["b"]`, 2},
+		{`{"a": 1}["z"]`, nil},
+		{`{1: "one", true: "yes", (1, 2): "pair"}[(1, 2)]`, `"pair"`},
+		{`let k = "x"; {k: 1}["x"]`, 1},
+		{`{"a": 1, "a": 2}`, `{"a": 2}`},
+		{`{"b": 1, "a": 2}`, `{"b": 1, "a": 2}`},
+		{`len({"a": 1, "b": 2})`, 2},
+		{`keys({"b": 1, "a": 2})`, `["b", "a"]`},
+		{`values({"b": 1, "a": 2})`, "[1, 2]"},
+		{`contains({"a": 1}, "a")`, true},
+		{`contains({"a": 1}, "b")`, false},
+		{`{"a": [1], "b": 2} == {"b": 2, "a": [1]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`[k for k in {"a": 1, "b": 2}]`, `["a", "b"]`},
+		{`let h = null; h?["a"]`, nil},
+		{`{"a": 1, "b": 2}.b`, 2},
+		{`{"a": 1}.z`, nil},
+		{`let cfg = {"server": {"name": "x"}}; cfg?.server?.name`, `"x"`},
+		{`let cfg = {}; cfg?.server?.name`, nil},
+		{`let cfg = null; cfg?.name`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			assert.Equal(t, expected, evaluated.Inspect())
+		default:
+			testNullObject(t, evaluated)
+		}
+	}
+
+	errors := []struct {
+		input    string
+		expected string
+	}{
+		{`{[1]: 2}`, "hash: can not use `ARRAY` as a hash key"},
+		{`{"a": 1}[[1]]`, "index expression: can not use `ARRAY` as a hash key"},
+		{`keys([1])`, "keys: argument 1 must be `HASH`, got `ARRAY`"},
+	}
+
+	for _, tt := range errors {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		text     string
+		input    string
+		expected string
+	}{
+		{`{"a": [1, 2.5, "x\u00e9"], "b": {"c": null, "d": true}}`, "json.parse(text)", `{"a": [1, 2.5, "xé"], "b": {"c": null, "d": true}}`},
+		{`{"z": 1, "a": 2}`, "keys(json.parse(text))", `["z", "a"]`},
+		{`[1.5, 12345678901234567890]`, `json.parse(text, {"numbers": "string"})`, `["1.5", "12345678901234567890"]`},
+		{`[1e2, 7]`, "json.parse(text)", "[100.0, 7]"},
+		{`"\"quoted\" <tag>"`, "json.stringify(json.parse(text))", `""\"quoted\" <tag>""`},
+		{`{"a": [1, {"b": []}], "c": {}}`, "json.stringify(json.parse(text))", `"{"a":[1,{"b":[]}],"c":{}}"`},
+		{"{\"a\": [1, 2]}", "json.stringify(json.parse(text), 2)", "\"{\n  \"a\": [\n    1,\n    2\n  ]\n}\""},
+		{"", `json.stringify({"t": (1, "x"), "s": #{true}, "f": 0.5, "n": null}, "")`, `"{"t":[1,"x"],"s":[true],"f":0.5,"n":null}"`},
+		{"", `json.stringify([1], "\t")`, "\"[\n\\t1\n]\""},
+		{`{"server": {"name": "x"}}`, "let cfg = json.parse(text); cfg?.server?.name", `"x"`},
+		{`{"server": {}}`, "let cfg = json.parse(text); cfg?.server?.name", "null"},
+	}
+
+	for _, tt := range tests {
+		env := object.NewEnvironment()
+		env.Set("text", &object.String{Value: tt.text})
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+		assert.Equal(t, tt.expected, evaluated.Inspect())
+	}
+
+	errors := []struct {
+		text     string
+		input    string
+		expected string
+	}{
+		{"{\"a\": 1,\n  \"b\": }", "json.parse(text)", "json.parse: missing value after object key (line 2, column 8)"},
+		{"[1, 2] x", "json.parse(text)", "json.parse: invalid character 'x' looking for beginning of value (line 1, column 8)"},
+		{"[1, 2] [3]", "json.parse(text)", "json.parse: unexpected data after JSON value (line 1, column 8)"},
+		{"[1.5]", `json.parse(text, {"numbers": "error"})`, "json.parse: 1.5 is not an integer (line 1, column 2)"},
+		{"[1e999]", "json.parse(text)", "json.parse: 1e999 is out of range (line 1, column 2)"},
+		{"[1]", `json.parse(text, {"numbers": "decimal"})`, `json.parse: numbers must be "float", "string" or "error", got "decimal"`},
+		{"[1]", `json.parse(text, {"strict": true})`, `json.parse: unknown option "strict"`},
+		{"", "json.parse(1)", "json.parse: argument 1 must be `STRING`, got `INTEGER`"},
+		{"", "json.stringify({1: 2})", "json.stringify: object keys must be strings, got `INTEGER`"},
+		{"", "json.stringify([fn(x) { x }])", "json.stringify: can not convert `FUNCTION` to JSON"},
+		{"", "json.stringify(1.0 / 0)", "json.stringify: can not convert +Inf to JSON"},
+		{"", "json.stringify(1, true)", "json.stringify: indent must be `INTEGER` or `STRING`, got `BOOLEAN`"},
+		{"", "json.stringify(1, 9223372036854775807)", "json.stringify: indent must not be more than 16777216. given 9223372036854775807"},
+		{"", "json.stringify([1], 3000000000)", "json.stringify: indent must not be more than 16777216. given 3000000000"},
+	}
+
+	for _, tt := range errors {
+		env := object.NewEnvironment()
+		env.Set("text", &object.String{Value: tt.text})
+
+		evaluated := Eval(parser.New(lexer.New(tt.input)).ParseProgram(), env)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !assert.Truef(t, ok, "error object not returned. got %T (%+v)", evaluated, evaluated) {
+			continue
+		}
+
+		assert.Equal(t, tt.expected, errObj.Message)
+	}
+}
+
 func testEval(input string) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
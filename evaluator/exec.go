@@ -0,0 +1,312 @@
+package evaluator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hummus-lang/object"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// waitDelay is how long a command's output is waited for once it has exited
+// or been killed, in case something it started is still holding it open
+const waitDelay = time.Second
+
+// commandRunner runs the programs the host allows scripts to run
+type commandRunner struct {
+	allowed map[string]string // command names, and the programs they run
+}
+
+// InstallExec defines exec and pipeline in env, allowing scripts to run only
+// the given commands. Running anything else fails with a permission error.
+// Commands are looked up in PATH once, here, so scripts changing PATH later
+// can not change what they run
+func InstallExec(env *object.Environment, commands ...string) error {
+	c := &commandRunner{allowed: map[string]string{}}
+	for _, command := range commands {
+		if strings.ContainsAny(command, `/\`) {
+			return fmt.Errorf("can not allow running %s: give a command name, not a path", command)
+		}
+
+		path, err := exec.LookPath(command)
+		if err == nil {
+			path, err = filepath.Abs(path)
+		}
+		if err != nil {
+			return fmt.Errorf("can not allow running %s: %v", command, err)
+		}
+		c.allowed[command] = path
+	}
+
+	env.Set("exec", &object.Predef{Function: c.execute})
+	env.Set("pipeline", &object.Predef{Function: c.pipeline})
+
+	return nil
+}
+
+type execOptions struct {
+	stdin   string
+	env     []string
+	cwd     string
+	timeout time.Duration
+}
+
+// execOptionsOf reads the hash of options exec and pipeline take: "stdin" is a
+// string to feed to the (first) command, "env" a hash of environment
+// variables to set, "cwd" the directory to run in, and "timeout" a duration or
+// a number of seconds after which the commands are killed
+func execOptionsOf(name string, obj object.Object) (*execOptions, *object.Error) {
+	hash, ok := obj.(*object.Hash)
+	if !ok {
+		return nil, newError("%s: options must be `HASH`, got `%s`", name, obj.Type())
+	}
+
+	opts := &execOptions{}
+	for _, pair := range hash.Items() {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return nil, newError("%s: unknown option %s", name, pair.Key.Inspect())
+		}
+
+		switch key.Value {
+		case "stdin", "cwd":
+			value, ok := pair.Value.(*object.String)
+			if !ok {
+				return nil, newError("%s: %s must be `STRING`, got `%s`", name, key.Value, pair.Value.Type())
+			}
+			if key.Value == "stdin" {
+				opts.stdin = value.Value
+			} else {
+				opts.cwd = value.Value
+			}
+
+		case "env":
+			variables, ok := pair.Value.(*object.Hash)
+			if !ok {
+				return nil, newError("%s: env must be `HASH`, got `%s`", name, pair.Value.Type())
+			}
+			opts.env = os.Environ()
+			for _, variable := range variables.Items() {
+				k, kOk := variable.Key.(*object.String)
+				v, vOk := variable.Value.(*object.String)
+				if !kOk || !vOk {
+					return nil, newError("%s: env must map strings to strings, got %s: %s", name, variable.Key.Inspect(), variable.Value.Inspect())
+				}
+				opts.env = append(opts.env, k.Value+"="+v.Value)
+			}
+
+		case "timeout":
+			if d, ok := pair.Value.(*object.Duration); ok {
+				opts.timeout = d.Value
+			} else if seconds, ok := floatValue(pair.Value); ok {
+				opts.timeout = time.Duration(seconds * float64(time.Second))
+			} else {
+				return nil, newError("%s: timeout must be `DURATION` or a number of seconds, got `%s`", name, pair.Value.Type())
+			}
+			if opts.timeout <= 0 {
+				return nil, newError("%s: timeout must be positive, got %s", name, pair.Value.Inspect())
+			}
+
+		default:
+			return nil, newError("%s: unknown option %s", name, pair.Key.Inspect())
+		}
+	}
+
+	return opts, nil
+}
+
+// command checks a command may be run, and builds it out of its name and an
+// array of arguments
+func (c *commandRunner) command(ctx context.Context, name string, program object.Object, args object.Object, opts *execOptions) (*exec.Cmd, *object.Error) {
+	str, ok := program.(*object.String)
+	if !ok {
+		return nil, newError("%s: command must be `STRING`, got `%s`", name, program.Type())
+	}
+
+	path, ok := c.allowed[str.Value]
+	if !ok {
+		return nil, newError("%s: permission denied: %s is not an allowed command", name, str.Value)
+	}
+
+	elements := []object.Object{}
+	if args != nil {
+		array, ok := args.(*object.Array)
+		if !ok {
+			return nil, newError("%s: arguments of %s must be `ARRAY`, got `%s`", name, str.Value, args.Type())
+		}
+		elements = array.Elements
+	}
+
+	arguments := make([]string, len(elements))
+	for i, e := range elements {
+		argument, ok := e.(*object.String)
+		if !ok {
+			return nil, newError("%s: arguments of %s must be strings, got `%s`", name, str.Value, e.Type())
+		}
+		arguments[i] = argument.Value
+	}
+
+	cmd := exec.CommandContext(ctx, path, arguments...)
+	cmd.Env = opts.env
+	cmd.Dir = opts.cwd
+	return cmd, nil
+}
+
+// execute runs a command with an optional array of arguments and hash of
+// options, returning a hash of what it wrote to stdout and stderr and its exit
+// code. A command exiting with a non-zero code is not an error
+func (c *commandRunner) execute(args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return newError("exec: expected between 1 and 3 arguments. given %d", len(args))
+	}
+
+	opts := &execOptions{}
+	if len(args) == 3 {
+		var err *object.Error
+		if opts, err = execOptionsOf("exec", args[2]); err != nil {
+			return err
+		}
+	}
+
+	var arguments object.Object
+	if len(args) > 1 {
+		arguments = args[1]
+	}
+
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	cmd, err := c.command(ctx, "exec", args[0], arguments, opts)
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr, codes, err := runCommands(ctx, "exec", []*exec.Cmd{cmd}, opts)
+	if err != nil {
+		return err
+	}
+
+	return execResult(stdout, stderr, codes[0])
+}
+
+// pipeline runs commands side by side, each reading what the one before it
+// writes, like `a | b | c` in a shell. Commands are given as an array of
+// tuples of a name and an array of arguments. It returns what exec would for
+// the last command, along with the exit codes of all of them as "codes"
+func (c *commandRunner) pipeline(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("pipeline: expected 1 or 2 arguments. given %d", len(args))
+	}
+
+	steps, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("pipeline: argument 1 must be `ARRAY`, got `%s`", args[0].Type())
+	}
+	if len(steps.Elements) == 0 {
+		return newError("pipeline: expected at least 1 command")
+	}
+
+	opts := &execOptions{}
+	if len(args) == 2 {
+		var err *object.Error
+		if opts, err = execOptionsOf("pipeline", args[1]); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := opts.context()
+	defer cancel()
+
+	commands := []*exec.Cmd{}
+	for _, step := range steps.Elements {
+		tuple, ok := step.(*object.Tuple)
+		if !ok || len(tuple.Elements) != 2 {
+			return newError("pipeline: commands must be tuples of a name and arguments, got %s", step.Inspect())
+		}
+
+		cmd, err := c.command(ctx, "pipeline", tuple.Elements[0], tuple.Elements[1], opts)
+		if err != nil {
+			return err
+		}
+		commands = append(commands, cmd)
+	}
+
+	stdout, stderr, codes, err := runCommands(ctx, "pipeline", commands, opts)
+	if err != nil {
+		return err
+	}
+
+	result := execResult(stdout, stderr, codes[len(codes)-1])
+	result.Set(&object.String{Value: "codes"}, &object.Array{Elements: codes})
+	return result
+}
+
+func (opts *execOptions) context() (context.Context, context.CancelFunc) {
+	if opts.timeout == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), opts.timeout)
+}
+
+// runCommands connects the commands into a pipeline, runs them all and waits
+// for them to finish. It returns what the last command wrote to stdout, what
+// they all wrote to stderr, and their exit codes
+func runCommands(ctx context.Context, name string, commands []*exec.Cmd, opts *execOptions) (string, string, []object.Object, *object.Error) {
+	var stdout bytes.Buffer
+	stderr := make([]bytes.Buffer, len(commands))
+
+	commands[0].Stdin = strings.NewReader(opts.stdin)
+	for i, cmd := range commands {
+		cmd.WaitDelay = waitDelay
+		cmd.Stderr = &stderr[i]
+		if i == len(commands)-1 {
+			cmd.Stdout = &stdout
+			continue
+		}
+
+		pipe, err := cmd.StdoutPipe()
+		if err != nil {
+			return "", "", nil, newError("%s: %v", name, err)
+		}
+		commands[i+1].Stdin = pipe
+	}
+
+	for i, cmd := range commands {
+		if err := cmd.Start(); err != nil {
+			for _, started := range commands[:i] {
+				started.Process.Kill()
+				started.Wait()
+			}
+			return "", "", nil, newError("%s: %v", name, err)
+		}
+	}
+
+	codes := make([]object.Object, len(commands))
+	for i, cmd := range commands {
+		cmd.Wait()
+		codes[i] = &object.Integer{Value: int64(cmd.ProcessState.ExitCode())}
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", "", nil, newError("%s: timed out after %s", name, opts.timeout)
+	}
+
+	var errors strings.Builder
+	for i := range stderr {
+		errors.Write(stderr[i].Bytes())
+	}
+
+	return stdout.String(), errors.String(), codes, nil
+}
+
+func execResult(stdout string, stderr string, code object.Object) *object.Hash {
+	result := object.NewHash()
+	result.Set(&object.String{Value: "stdout"}, &object.String{Value: stdout})
+	result.Set(&object.String{Value: "stderr"}, &object.String{Value: stderr})
+	result.Set(&object.String{Value: "code"}, code)
+	return result
+}
@@ -0,0 +1,425 @@
+package evaluator
+
+import (
+	"bufio"
+	"fmt"
+	"hummus-lang/object"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	predefs["readLine"] = &object.Predef{Function: readLine}
+	predefs["readAll"] = &object.Predef{Function: readAll}
+	predefs["eachLine"] = &object.Predef{Function: eachLine}
+	predefs["write"] = &object.Predef{Function: write}
+	predefs["close"] = &object.Predef{Function: closeFile}
+}
+
+// sandbox limits file access to the files under a set of root directories
+type sandbox struct {
+	roots []string
+}
+
+// InstallFileAccess defines the file predefs in env, allowing access only to
+// files under roots. Relative paths are resolved against the first root. With
+// no roots, every file is out of reach
+func InstallFileAccess(env *object.Environment, roots ...string) error {
+	s := &sandbox{}
+	for _, root := range roots {
+		resolved, err := filepath.Abs(root)
+		if err == nil {
+			resolved, err = filepath.EvalSymlinks(resolved)
+		}
+		if err != nil {
+			return fmt.Errorf("can not allow access to %s: %v", root, err)
+		}
+		s.roots = append(s.roots, resolved)
+	}
+
+	functions := map[string]object.PredefFunction{
+		"readFile":   s.readFile,
+		"writeFile":  s.writeFile("writeFile", os.O_TRUNC),
+		"appendFile": s.writeFile("appendFile", os.O_APPEND),
+		"readLines":  s.readLines,
+		"listDir":    s.listDir,
+		"exists":     s.exists,
+		"mkdir":      s.mkdir,
+		"remove":     s.remove,
+		"open":       s.open,
+	}
+
+	for name, f := range functions {
+		env.Set(name, &object.Predef{Function: f})
+	}
+
+	return nil
+}
+
+// resolve turns the path a script gave into an absolute one, making sure it
+// does not lead outside of the sandbox, including through symlinks
+func (s *sandbox) resolve(name string, path string) (string, *object.Error) {
+	full := s.absolute(path)
+
+	// symlinks can only be followed in the part of the path that exists
+	existing, rest := full, ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", newError("%s: %s", name, describeFileError(err, path))
+	}
+	resolved = filepath.Join(resolved, rest)
+
+	if !s.contains(resolved) {
+		return "", newError("%s: access denied: %s is outside of the allowed directories", name, path)
+	}
+
+	return resolved, nil
+}
+
+// absolute cleans up path, making it absolute if it is not already
+func (s *sandbox) absolute(path string) string {
+	if !filepath.IsAbs(path) {
+		if len(s.roots) == 0 {
+			return filepath.Clean(path)
+		}
+		path = filepath.Join(s.roots[0], path)
+	}
+	return filepath.Clean(path)
+}
+
+// contains reports whether a resolved path is under one of the roots
+func (s *sandbox) contains(path string) bool {
+	for _, root := range s.roots {
+		if rel, err := filepath.Rel(root, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathArgument checks a file predef was given a path, and the expected
+// arguments after it, and resolves the path
+func (s *sandbox) pathArgument(name string, args []object.Object, types ...object.ObjectType) (string, *object.Error) {
+	if err := checkArguments(name, args, append([]object.ObjectType{object.StringObj}, types...)...); err != nil {
+		return "", err
+	}
+
+	return s.resolve(name, args[0].(*object.String).Value)
+}
+
+// describeFileError reports an error about a file using the path the script
+// gave, rather than where it was resolved to
+func describeFileError(err error, path string) string {
+	if pathErr, ok := err.(*os.PathError); ok {
+		return fmt.Sprintf("%s %s: %v", pathErr.Op, path, pathErr.Err)
+	}
+	return err.Error()
+}
+
+func (s *sandbox) readFile(args ...object.Object) object.Object {
+	path, err := s.pathArgument("readFile", args)
+	if err != nil {
+		return err
+	}
+
+	contents, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return newError("readFile: %s", describeFileError(readErr, args[0].Printable()))
+	}
+
+	return &object.String{Value: string(contents)}
+}
+
+// writeFile builds writeFile and appendFile, which differ in whether they
+// replace the contents of an existing file
+func (s *sandbox) writeFile(name string, mode int) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		path, err := s.pathArgument(name, args, object.StringObj)
+		if err != nil {
+			return err
+		}
+
+		f, openErr := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|mode, 0644)
+		if openErr != nil {
+			return newError("%s: %s", name, describeFileError(openErr, args[0].Printable()))
+		}
+
+		_, writeErr := io.WriteString(f, args[1].(*object.String).Value)
+		closeErr := f.Close()
+		if writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			return newError("%s: %s", name, describeFileError(writeErr, args[0].Printable()))
+		}
+
+		return Null
+	}
+}
+
+// readLines reads a file as an array of lines, without their line endings
+func (s *sandbox) readLines(args ...object.Object) object.Object {
+	path, err := s.pathArgument("readLines", args)
+	if err != nil {
+		return err
+	}
+
+	contents, readErr := ioutil.ReadFile(path)
+	if readErr != nil {
+		return newError("readLines: %s", describeFileError(readErr, args[0].Printable()))
+	}
+
+	text := strings.TrimSuffix(strings.ReplaceAll(string(contents), "\r\n", "\n"), "\n")
+	if text == "" {
+		return &object.Array{Elements: []object.Object{}}
+	}
+
+	return stringsToArray(strings.Split(text, "\n"))
+}
+
+// listDir returns the names of the entries of a directory, sorted
+func (s *sandbox) listDir(args ...object.Object) object.Object {
+	path, err := s.pathArgument("listDir", args)
+	if err != nil {
+		return err
+	}
+
+	entries, readErr := ioutil.ReadDir(path)
+	if readErr != nil {
+		return newError("listDir: %s", describeFileError(readErr, args[0].Printable()))
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return stringsToArray(names)
+}
+
+func (s *sandbox) exists(args ...object.Object) object.Object {
+	path, err := s.pathArgument("exists", args)
+	if err != nil {
+		return err
+	}
+
+	_, statErr := os.Stat(path)
+	return nativeBoolToBooleanObject(statErr == nil)
+}
+
+// mkdir creates a directory along with any missing parents
+func (s *sandbox) mkdir(args ...object.Object) object.Object {
+	path, err := s.pathArgument("mkdir", args)
+	if err != nil {
+		return err
+	}
+
+	if mkdirErr := os.MkdirAll(path, 0755); mkdirErr != nil {
+		return newError("mkdir: %s", describeFileError(mkdirErr, args[0].Printable()))
+	}
+
+	return Null
+}
+
+// remove deletes a file or an empty directory. The allowed directories
+// themselves can not be removed
+func (s *sandbox) remove(args ...object.Object) object.Object {
+	if err := checkArguments("remove", args, object.StringObj); err != nil {
+		return err
+	}
+
+	// a symlink is removed itself rather than what it points to, so only the
+	// directory holding it is resolved
+	given := args[0].(*object.String).Value
+	full := s.absolute(given)
+	for _, root := range s.roots {
+		if full == root {
+			return newError("remove: access denied: can not remove %s", given)
+		}
+	}
+
+	dir, err := s.resolve("remove", filepath.Dir(full))
+	path := filepath.Join(dir, filepath.Base(full))
+	if err != nil || !s.contains(path) {
+		return newError("remove: access denied: %s is outside of the allowed directories", given)
+	}
+	for _, root := range s.roots {
+		if path == root {
+			return newError("remove: access denied: can not remove %s", given)
+		}
+	}
+
+	if _, statErr := os.Lstat(path); statErr != nil {
+		return newError("remove: %s", describeFileError(statErr, given))
+	}
+
+	if removeErr := os.Remove(path); removeErr != nil {
+		return newError("remove: %s", describeFileError(removeErr, args[0].Printable()))
+	}
+
+	return Null
+}
+
+var openModes = map[string]int{
+	"r": os.O_RDONLY,
+	"w": os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"a": os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+}
+
+// open opens a file to be read line by line with readLine ("r"), or written
+// to bit by bit with write ("w" to replace its contents, "a" to append)
+func (s *sandbox) open(args ...object.Object) object.Object {
+	path, err := s.pathArgument("open", args, object.StringObj)
+	if err != nil {
+		return err
+	}
+
+	mode := args[1].(*object.String).Value
+	flag, ok := openModes[mode]
+	if !ok {
+		return newError("open: unknown mode %q, expected \"r\", \"w\" or \"a\"", mode)
+	}
+
+	f, openErr := os.OpenFile(path, flag, 0644)
+	if openErr != nil {
+		return newError("open: %s", describeFileError(openErr, args[0].Printable()))
+	}
+
+	file := &object.File{Name: args[0].(*object.String).Value, Closer: f}
+	if mode == "r" {
+		file.Reader = bufio.NewReader(f)
+	} else {
+		file.Writer = f
+	}
+
+	return file
+}
+
+// fileArgument checks a predef was given an open file, and the expected
+// arguments after it
+func fileArgument(name string, args []object.Object, types ...object.ObjectType) (*object.File, *object.Error) {
+	if err := checkArguments(name, args, append([]object.ObjectType{object.FileObj}, types...)...); err != nil {
+		return nil, err
+	}
+
+	file := args[0].(*object.File)
+	if file.Closed {
+		return nil, newError("%s: %s is closed", name, file.Inspect())
+	}
+
+	return file, nil
+}
+
+// readLine reads the next line of a file, without its line ending. It returns
+// null once there is nothing left to read
+func readLine(args ...object.Object) object.Object {
+	file, err := fileArgument("readLine", args)
+	if err != nil {
+		return err
+	}
+
+	if file.Reader == nil {
+		return newError("readLine: %s was not opened for reading", file.Inspect())
+	}
+
+	line, readErr := file.Reader.ReadString('\n')
+	if readErr == io.EOF && line == "" {
+		return Null
+	}
+	if readErr != nil && readErr != io.EOF {
+		return newError("readLine: %s", describeFileError(readErr, file.Name))
+	}
+
+	return &object.String{Value: strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")}
+}
+
+// readAll reads the rest of a file, returning an empty string if there is
+// nothing left
+func readAll(args ...object.Object) object.Object {
+	file, err := fileArgument("readAll", args)
+	if err != nil {
+		return err
+	}
+
+	if file.Reader == nil {
+		return newError("readAll: %s was not opened for reading", file.Inspect())
+	}
+
+	contents, readErr := ioutil.ReadAll(file.Reader)
+	if readErr != nil {
+		return newError("readAll: %s", describeFileError(readErr, file.Name))
+	}
+
+	return &object.String{Value: string(contents)}
+}
+
+// eachLine calls a function with each line of a file in turn, reading the
+// next line only once the function has returned
+func eachLine(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("eachLine: expected exactly 2 arguments. given %d", len(args))
+	}
+
+	if _, err := fileArgument("eachLine", args[:1]); err != nil {
+		return err
+	}
+
+	for {
+		line := readLine(args[0])
+		if isError(line) || line == Null {
+			return line
+		}
+
+		if result := call(args[1], line); isError(result) {
+			return result
+		}
+	}
+}
+
+func write(args ...object.Object) object.Object {
+	file, err := fileArgument("write", args, object.StringObj)
+	if err != nil {
+		return err
+	}
+
+	if file.Writer == nil {
+		return newError("write: %s was not opened for writing", file.Inspect())
+	}
+
+	if _, writeErr := io.WriteString(file.Writer, args[1].(*object.String).Value); writeErr != nil {
+		return newError("write: %s", describeFileError(writeErr, file.Name))
+	}
+
+	return Null
+}
+
+func closeFile(args ...object.Object) object.Object {
+	file, err := fileArgument("close", args)
+	if err != nil {
+		return err
+	}
+
+	file.Closed = true
+	if file.Closer != nil {
+		if closeErr := file.Closer.Close(); closeErr != nil {
+			return newError("close: %s", describeFileError(closeErr, file.Name))
+		}
+	}
+
+	return Null
+}
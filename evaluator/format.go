@@ -0,0 +1,146 @@
+package evaluator
+
+import (
+	"fmt"
+	"hummus-lang/object"
+	"strings"
+)
+
+func init() {
+	predefs["format"] = &object.Predef{Function: format}
+	predefs["printf"] = &object.Predef{Function: printf}
+}
+
+// format fills in the verbs of a format string with the arguments after it,
+// in order. Verbs are written like Go's, with optional flags, a width and a
+// precision between the % and the letter:
+//
+//	%d %x %X %o %b   integers, in base 10, 16, 8 or 2
+//	%s %q            strings, as they are or quoted
+//	%f %e %g         numbers, as decimals, with an exponent, or whichever is shorter
+//	%v %#v           any value, as printLine or the REPL would show it
+//	%%               a percent sign
+func format(args ...object.Object) object.Object {
+	formatted, err := formatArguments("format", args)
+	if err != nil {
+		return err
+	}
+
+	return &object.String{Value: formatted}
+}
+
+// printf prints its arguments the way format would lay them out
+func printf(args ...object.Object) object.Object {
+	formatted, err := formatArguments("printf", args)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(formatted)
+	return Null
+}
+
+func formatArguments(name string, args []object.Object) (string, *object.Error) {
+	if len(args) == 0 {
+		return "", newError("%s: expected at least 1 argument. given 0", name)
+	}
+
+	layout, ok := args[0].(*object.String)
+	if !ok {
+		return "", newError("%s: argument 1 must be `STRING`, got `%s`", name, args[0].Type())
+	}
+
+	text, values := layout.Value, args[1:]
+	used := 0
+
+	var out strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '%' {
+			out.WriteByte(text[i])
+			continue
+		}
+
+		start := i
+		i = skipWhile(text, i+1, func(c byte) bool { return strings.IndexByte("-+# 0", c) >= 0 })
+		i = skipWhile(text, i, isDigit)
+		if i < len(text) && text[i] == '.' {
+			i = skipWhile(text, i+1, isDigit)
+		}
+
+		if i == len(text) {
+			return "", newError("%s: unfinished verb %q at the end of the format", name, text[start:])
+		}
+
+		spec, verb := text[start:i], text[i]
+		if verb == '%' && spec == "%" {
+			out.WriteByte('%')
+			continue
+		}
+
+		if used == len(values) {
+			return "", newError("%s: missing argument for %s", name, text[start:i+1])
+		}
+
+		formatted, err := formatValue(name, spec, verb, values[used])
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(formatted)
+		used++
+	}
+
+	if used < len(values) {
+		return "", newError("%s: %d arguments given but only %d used", name, len(values), used)
+	}
+
+	return out.String(), nil
+}
+
+// skipWhile returns the index of the first byte of text from i on that is not
+// matched by f
+func skipWhile(text string, i int, f func(c byte) bool) int {
+	for i < len(text) && f(text[i]) {
+		i++
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// formatValue lays out a single value for a verb. spec is everything from the
+// % up to the verb's letter
+func formatValue(name string, spec string, verb byte, value object.Object) (string, *object.Error) {
+	switch verb {
+	case 'v':
+		if strings.Contains(spec, "#") {
+			return fmt.Sprintf(strings.Replace(spec, "#", "", -1)+"s", value.Inspect()), nil
+		}
+		return fmt.Sprintf(spec+"s", value.Printable()), nil
+
+	case 'd', 'x', 'X', 'o', 'b':
+		integer, ok := value.(*object.Integer)
+		if !ok {
+			return "", newError("%s: %s%c needs `INTEGER`, got `%s`", name, spec, verb, value.Type())
+		}
+		return fmt.Sprintf(spec+string(verb), integer.Value), nil
+
+	case 's', 'q':
+		str, ok := value.(*object.String)
+		if !ok {
+			return "", newError("%s: %s%c needs `STRING`, got `%s`", name, spec, verb, value.Type())
+		}
+		return fmt.Sprintf(spec+string(verb), str.Value), nil
+
+	case 'f', 'e', 'g':
+		number, ok := floatValue(value)
+		if !ok {
+			return "", newError("%s: %s%c needs a number, got `%s`", name, spec, verb, value.Type())
+		}
+		return fmt.Sprintf(spec+string(verb), number), nil
+
+	default:
+		return "", newError("%s: unknown verb %s%c", name, spec, verb)
+	}
+}
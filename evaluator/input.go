@@ -0,0 +1,29 @@
+package evaluator
+
+import (
+	"bufio"
+	"hummus-lang/object"
+	"io"
+)
+
+// InstallInput defines `stdin` in env as a file reading from in, and lets
+// readLine, readAll and eachLine be called without a file to read from it
+func InstallInput(env *object.Environment, in io.Reader) {
+	stdin := &object.File{Name: "stdin", Reader: bufio.NewReader(in)}
+	env.Set("stdin", stdin)
+
+	env.Set("readLine", &object.Predef{Function: withDefaultFile(stdin, 1, readLine)})
+	env.Set("readAll", &object.Predef{Function: withDefaultFile(stdin, 1, readAll)})
+	env.Set("eachLine", &object.Predef{Function: withDefaultFile(stdin, 2, eachLine)})
+}
+
+// withDefaultFile makes the file argument of a predef taking n arguments
+// optional, passing it file when it is left out
+func withDefaultFile(file *object.File, n int, f object.PredefFunction) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) == n-1 {
+			args = append([]object.Object{file}, args...)
+		}
+		return f(args...)
+	}
+}
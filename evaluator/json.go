@@ -0,0 +1,364 @@
+package evaluator
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hummus-lang/object"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+func init() {
+	modules["json"] = &object.Module{
+		Name: "json",
+		Members: map[string]object.Object{
+			"parse":     &object.Predef{Function: parseJSON},
+			"stringify": &object.Predef{Function: stringifyJSON},
+		},
+	}
+}
+
+// numberModes are the ways json.parse can treat numbers that are not
+// integers, or that do not fit in one
+var numberModes = map[string]bool{
+	"float":  true,
+	"string": true,
+	"error":  true,
+}
+
+type jsonParser struct {
+	text    string
+	decoder *json.Decoder
+	numbers string
+}
+
+// jsonError is a problem with the input to json.parse, found offset bytes in
+type jsonError struct {
+	message string
+	offset  int64
+}
+
+// parseJSON converts JSON to hummus values, with objects becoming hashes.
+// Numbers that are not integers become floats, unless the options hash says
+// otherwise: `{"numbers": "string"}` keeps their text as a string, and
+// `{"numbers": "error"}` rejects them
+func parseJSON(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("json.parse: expected 1 or 2 arguments. given %d", len(args))
+	}
+
+	text, ok := args[0].(*object.String)
+	if !ok {
+		return newError("json.parse: argument 1 must be `STRING`, got `%s`", args[0].Type())
+	}
+
+	p := &jsonParser{text: text.Value, decoder: json.NewDecoder(strings.NewReader(text.Value)), numbers: "float"}
+	p.decoder.UseNumber()
+
+	if len(args) == 2 {
+		if err := p.configure(args[1]); err != nil {
+			return err
+		}
+	}
+
+	value, err := p.value()
+	if err == nil {
+		if _, trailing := p.decoder.Token(); trailing != io.EOF {
+			err = p.describe(trailing, "unexpected data after JSON value")
+		}
+	}
+	if err != nil {
+		line, column := p.position(err.offset)
+		return newError("json.parse: %s (line %d, column %d)", err.message, line, column)
+	}
+
+	return value
+}
+
+func (p *jsonParser) configure(options object.Object) *object.Error {
+	hash, ok := options.(*object.Hash)
+	if !ok {
+		return newError("json.parse: argument 2 must be `HASH`, got `%s`", options.Type())
+	}
+
+	for _, pair := range hash.Items() {
+		if key, ok := pair.Key.(*object.String); !ok || key.Value != "numbers" {
+			return newError("json.parse: unknown option %s", pair.Key.Inspect())
+		}
+
+		mode, ok := pair.Value.(*object.String)
+		if !ok || !numberModes[mode.Value] {
+			return newError("json.parse: numbers must be \"float\", \"string\" or \"error\", got %s", pair.Value.Inspect())
+		}
+		p.numbers = mode.Value
+	}
+
+	return nil
+}
+
+// describe turns an error from the decoder into a jsonError. err is nil when
+// the decoder read something valid that was not expected, which is explained
+// by message
+func (p *jsonParser) describe(err error, message string) *jsonError {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return &jsonError{message: e.Error(), offset: e.Offset - 1}
+	case nil:
+		return &jsonError{message: message, offset: p.decoder.InputOffset() - 1}
+	default:
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return &jsonError{message: "unexpected end of JSON input", offset: int64(len(p.text))}
+		}
+		return &jsonError{message: err.Error(), offset: p.decoder.InputOffset()}
+	}
+}
+
+// position finds the line and column, counting from 1, of a byte offset
+func (p *jsonParser) position(offset int64) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > int64(len(p.text)) {
+		offset = int64(len(p.text))
+	}
+
+	before := p.text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:]) + 1
+
+	return line, column
+}
+
+func (p *jsonParser) value() (object.Object, *jsonError) {
+	tok, err := p.decoder.Token()
+	if err != nil {
+		return nil, p.describe(err, "")
+	}
+
+	switch tok := tok.(type) {
+	case json.Delim:
+		if tok == '[' {
+			return p.array()
+		}
+		return p.object()
+	case string:
+		return &object.String{Value: tok}, nil
+	case bool:
+		return nativeBoolToBooleanObject(tok), nil
+	case json.Number:
+		return p.number(tok)
+	default:
+		return Null, nil
+	}
+}
+
+func (p *jsonParser) array() (object.Object, *jsonError) {
+	elements := []object.Object{}
+	for p.decoder.More() {
+		element, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+
+	if _, err := p.decoder.Token(); err != nil {
+		return nil, p.describe(err, "")
+	}
+
+	return &object.Array{Elements: elements}, nil
+}
+
+func (p *jsonParser) object() (object.Object, *jsonError) {
+	hash := object.NewHash()
+	for p.decoder.More() {
+		key, err := p.decoder.Token()
+		if err != nil {
+			return nil, p.describe(err, "")
+		}
+
+		value, valueErr := p.value()
+		if valueErr != nil {
+			return nil, valueErr
+		}
+
+		hash.Set(&object.String{Value: key.(string)}, value)
+	}
+
+	if _, err := p.decoder.Token(); err != nil {
+		return nil, p.describe(err, "")
+	}
+
+	return hash, nil
+}
+
+func (p *jsonParser) number(n json.Number) (object.Object, *jsonError) {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return &object.Integer{Value: i}, nil
+	}
+
+	switch p.numbers {
+	case "string":
+		return &object.String{Value: string(n)}, nil
+	case "error":
+		return nil, &jsonError{message: fmt.Sprintf("%s is not an integer", n), offset: p.decoder.InputOffset() - int64(len(n))}
+	default:
+		f, err := strconv.ParseFloat(string(n), 64)
+		if err != nil {
+			return nil, &jsonError{message: fmt.Sprintf("%s is out of range", n), offset: p.decoder.InputOffset() - int64(len(n))}
+		}
+		return &object.Float{Value: f}, nil
+	}
+}
+
+// stringifyJSON converts a value to JSON. Tuples and sets become arrays, and
+// hashes become objects if all their keys are strings. With a second argument,
+// the JSON is spread over several lines and indented by that many spaces, or
+// by that string
+func stringifyJSON(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("json.stringify: expected 1 or 2 arguments. given %d", len(args))
+	}
+
+	indent := ""
+	if len(args) == 2 {
+		switch x := args[1].(type) {
+		case *object.Integer:
+			if x.Value < 0 {
+				return newError("json.stringify: indent must not be negative. given %d", x.Value)
+			}
+			if x.Value > maxStringLength {
+				return newError("json.stringify: indent must not be more than %d. given %d", maxStringLength, x.Value)
+			}
+			indent = strings.Repeat(" ", int(x.Value))
+		case *object.String:
+			indent = x.Value
+		default:
+			return newError("json.stringify: indent must be `INTEGER` or `STRING`, got `%s`", args[1].Type())
+		}
+	}
+
+	var out bytes.Buffer
+	if err := writeJSON(&out, args[0], indent, 0, map[object.Object]bool{}); err != nil {
+		return err
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func writeJSON(out *bytes.Buffer, obj object.Object, indent string, depth int, visiting map[object.Object]bool) *object.Error {
+	// arrays and hashes can be changed to hold themselves, which JSON can not
+	// show
+	switch obj.(type) {
+	case *object.Array, *object.Hash:
+		if visiting[obj] {
+			return newError("json.stringify: can not convert a value that contains itself to JSON")
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+	}
+
+	switch x := obj.(type) {
+	case *object.Null:
+		out.WriteString("null")
+	case *object.Boolean:
+		out.WriteString(strconv.FormatBool(x.Value))
+	case *object.Integer:
+		out.WriteString(strconv.FormatInt(x.Value, 10))
+	case *object.Float:
+		if math.IsNaN(x.Value) || math.IsInf(x.Value, 0) {
+			return newError("json.stringify: can not convert %s to JSON", x.Inspect())
+		}
+		out.WriteString(strconv.FormatFloat(x.Value, 'g', -1, 64))
+	case *object.String:
+		writeJSONString(out, x.Value)
+	case *object.Array:
+		return writeJSONArray(out, x.Elements, indent, depth, visiting)
+	case *object.Tuple:
+		return writeJSONArray(out, x.Elements, indent, depth, visiting)
+	case *object.Set:
+		return writeJSONArray(out, x.Items(), indent, depth, visiting)
+	case *object.Hash:
+		return writeJSONObject(out, x, indent, depth, visiting)
+	default:
+		return newError("json.stringify: can not convert `%s` to JSON", obj.Type())
+	}
+
+	return nil
+}
+
+func writeJSONString(out *bytes.Buffer, s string) {
+	encoder := json.NewEncoder(out)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	out.Truncate(out.Len() - 1)
+}
+
+// writeJSONSeparator starts a new line for the next element of an array or
+// object, when indenting
+func writeJSONSeparator(out *bytes.Buffer, indent string, depth int) {
+	if indent != "" {
+		out.WriteString("\n")
+		out.WriteString(strings.Repeat(indent, depth))
+	}
+}
+
+func writeJSONArray(out *bytes.Buffer, elements []object.Object, indent string, depth int, visiting map[object.Object]bool) *object.Error {
+	if len(elements) == 0 {
+		out.WriteString("[]")
+		return nil
+	}
+
+	out.WriteString("[")
+	for i, e := range elements {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		writeJSONSeparator(out, indent, depth+1)
+		if err := writeJSON(out, e, indent, depth+1, visiting); err != nil {
+			return err
+		}
+	}
+	writeJSONSeparator(out, indent, depth)
+	out.WriteString("]")
+
+	return nil
+}
+
+func writeJSONObject(out *bytes.Buffer, hash *object.Hash, indent string, depth int, visiting map[object.Object]bool) *object.Error {
+	pairs := hash.Items()
+	if len(pairs) == 0 {
+		out.WriteString("{}")
+		return nil
+	}
+
+	out.WriteString("{")
+	for i, pair := range pairs {
+		key, ok := pair.Key.(*object.String)
+		if !ok {
+			return newError("json.stringify: object keys must be strings, got `%s`", pair.Key.Type())
+		}
+
+		if i > 0 {
+			out.WriteString(",")
+		}
+		writeJSONSeparator(out, indent, depth+1)
+		writeJSONString(out, key.Value)
+		if indent != "" {
+			out.WriteString(": ")
+		} else {
+			out.WriteString(":")
+		}
+		if err := writeJSON(out, pair.Value, indent, depth+1, visiting); err != nil {
+			return err
+		}
+	}
+	writeJSONSeparator(out, indent, depth)
+	out.WriteString("}")
+
+	return nil
+}
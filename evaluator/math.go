@@ -0,0 +1,328 @@
+package evaluator
+
+import (
+	"fmt"
+	"hummus-lang/object"
+	"math"
+)
+
+func init() {
+	modules["math"] = &object.Module{
+		Name: "math",
+		Members: map[string]object.Object{
+			"PI":      &object.Float{Value: math.Pi},
+			"E":       &object.Float{Value: math.E},
+			"MAX_INT": &object.Integer{Value: math.MaxInt64},
+			"MIN_INT": &object.Integer{Value: math.MinInt64},
+
+			"abs":   &object.Predef{Function: abs},
+			"min":   &object.Predef{Function: extreme("math.min", -1)},
+			"max":   &object.Predef{Function: extreme("math.max", 1)},
+			"pow":   &object.Predef{Function: pow},
+			"sqrt":  &object.Predef{Function: floatFunction("math.sqrt", math.Sqrt, nonNegative)},
+			"floor": &object.Predef{Function: rounding("math.floor", math.Floor)},
+			"ceil":  &object.Predef{Function: rounding("math.ceil", math.Ceil)},
+			"round": &object.Predef{Function: rounding("math.round", math.Round)},
+			"log":   &object.Predef{Function: floatFunction("math.log", math.Log, positive)},
+			"exp":   &object.Predef{Function: floatFunction("math.exp", math.Exp, nil)},
+			"sin":   &object.Predef{Function: floatFunction("math.sin", math.Sin, nil)},
+			"cos":   &object.Predef{Function: floatFunction("math.cos", math.Cos, nil)},
+			"tan":   &object.Predef{Function: floatFunction("math.tan", math.Tan, nil)},
+			"asin":  &object.Predef{Function: floatFunction("math.asin", math.Asin, between(-1, 1))},
+			"acos":  &object.Predef{Function: floatFunction("math.acos", math.Acos, between(-1, 1))},
+			"atan":  &object.Predef{Function: floatFunction("math.atan", math.Atan, nil)},
+			"atan2": &object.Predef{Function: atan2},
+			"gcd":   &object.Predef{Function: gcd},
+			"lcm":   &object.Predef{Function: lcm},
+			"clamp": &object.Predef{Function: clamp},
+		},
+	}
+}
+
+// numberArguments makes sure a predef was given exactly n numbers, and
+// converts them to floats
+func numberArguments(name string, args []object.Object, n int) ([]float64, *object.Error) {
+	if len(args) != n {
+		if n == 1 {
+			return nil, newError("%s: expected exactly 1 argument. given %d", name, len(args))
+		}
+		return nil, newError("%s: expected exactly %d arguments. given %d", name, n, len(args))
+	}
+
+	values := make([]float64, n)
+	for i, a := range args {
+		value, ok := floatValue(a)
+		if !ok {
+			return nil, newError("%s: argument %d must be a number, got `%s`", name, i+1, a.Type())
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// a domain describes the arguments a float function is defined for
+type domain struct {
+	contains    func(x float64) bool
+	description string
+}
+
+var (
+	nonNegative = &domain{func(x float64) bool { return x >= 0 }, "must not be negative"}
+	positive    = &domain{func(x float64) bool { return x > 0 }, "must be positive"}
+)
+
+func between(min float64, max float64) *domain {
+	return &domain{func(x float64) bool { return min <= x && x <= max }, fmt.Sprintf("must be between %g and %g", min, max)}
+}
+
+// floatFunction builds a predef out of a function on floats, reporting an
+// error for arguments outside of its domain
+func floatFunction(name string, f func(float64) float64, d *domain) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		values, err := numberArguments(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		if d != nil && !d.contains(values[0]) {
+			return newError("%s: argument %s, got %s", name, d.description, args[0].Inspect())
+		}
+
+		return &object.Float{Value: f(values[0])}
+	}
+}
+
+// rounding builds floor, ceil and round, which turn floats into integers
+func rounding(name string, f func(float64) float64) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		values, err := numberArguments(name, args, 1)
+		if err != nil {
+			return err
+		}
+
+		if integer, ok := args[0].(*object.Integer); ok {
+			return integer
+		}
+
+		rounded := f(values[0])
+		if math.IsNaN(rounded) || rounded < math.MinInt64 || rounded >= math.MaxInt64 {
+			return newError("%s: %s is out of integer range", name, args[0].Inspect())
+		}
+
+		return &object.Integer{Value: int64(rounded)}
+	}
+}
+
+func abs(args ...object.Object) object.Object {
+	if _, err := numberArguments("math.abs", args, 1); err != nil {
+		return err
+	}
+
+	switch x := args[0].(type) {
+	case *object.Integer:
+		if x.Value == math.MinInt64 {
+			return newError("math.abs: integer overflow")
+		}
+		if x.Value < 0 {
+			return &object.Integer{Value: -x.Value}
+		}
+		return x
+	default:
+		return &object.Float{Value: math.Abs(x.(*object.Float).Value)}
+	}
+}
+
+// compareNumbers orders two numbers, comparing integers exactly
+func compareNumbers(a object.Object, b object.Object) int {
+	if x, ok := a.(*object.Integer); ok {
+		if y, ok := b.(*object.Integer); ok {
+			switch {
+			case x.Value < y.Value:
+				return -1
+			case x.Value > y.Value:
+				return 1
+			default:
+				return 0
+			}
+		}
+	}
+
+	x, _ := floatValue(a)
+	y, _ := floatValue(b)
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// extreme builds min and max, which take either several numbers or a single
+// array of them. direction is -1 to find the smallest and 1 for the largest
+func extreme(name string, direction int) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		values := args
+		if len(args) == 1 {
+			if array, ok := args[0].(*object.Array); ok {
+				values = array.Elements
+			}
+		}
+
+		if len(values) == 0 {
+			return newError("%s: expected at least 1 number", name)
+		}
+
+		result := values[0]
+		for _, v := range values {
+			if _, ok := floatValue(v); !ok {
+				return newError("%s: can only compare numbers, got `%s`", name, v.Type())
+			}
+			if compareNumbers(v, result) == direction {
+				result = v
+			}
+		}
+
+		return result
+	}
+}
+
+// pow raises integers to integer powers exactly, and works in floats when
+// either argument is a float
+func pow(args ...object.Object) object.Object {
+	values, err := numberArguments("math.pow", args, 2)
+	if err != nil {
+		return err
+	}
+
+	base, baseIsInt := args[0].(*object.Integer)
+	exponent, exponentIsInt := args[1].(*object.Integer)
+	if !baseIsInt || !exponentIsInt {
+		result := math.Pow(values[0], values[1])
+		if math.IsNaN(result) {
+			return newError("math.pow: %s to the power of %s is not a number", args[0].Inspect(), args[1].Inspect())
+		}
+		return &object.Float{Value: result}
+	}
+
+	if exponent.Value < 0 {
+		return newError("math.pow: integer exponent must not be negative, got %d", exponent.Value)
+	}
+
+	result := int64(1)
+	for i := int64(0); i < exponent.Value; i++ {
+		next, ok := multiply(result, base.Value)
+		if !ok {
+			return newError("math.pow: integer overflow")
+		}
+		result = next
+
+		if result == 0 || result == 1 {
+			break
+		}
+		if result == -1 {
+			if (exponent.Value-i-1)%2 == 1 {
+				result = 1
+			}
+			break
+		}
+	}
+
+	return &object.Integer{Value: result}
+}
+
+// multiply multiplies two integers, reporting false if the result overflows
+func multiply(a int64, b int64) (int64, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+
+	result := a * b
+	if result/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, false
+	}
+
+	return result, true
+}
+
+func atan2(args ...object.Object) object.Object {
+	values, err := numberArguments("math.atan2", args, 2)
+	if err != nil {
+		return err
+	}
+
+	return &object.Float{Value: math.Atan2(values[0], values[1])}
+}
+
+func gcdOf(a int64, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+func gcd(args ...object.Object) object.Object {
+	if err := checkArguments("math.gcd", args, object.IntegerObj, object.IntegerObj); err != nil {
+		return err
+	}
+
+	result := gcdOf(args[0].(*object.Integer).Value, args[1].(*object.Integer).Value)
+	if result < 0 {
+		return newError("math.gcd: integer overflow")
+	}
+
+	return &object.Integer{Value: result}
+}
+
+func lcm(args ...object.Object) object.Object {
+	if err := checkArguments("math.lcm", args, object.IntegerObj, object.IntegerObj); err != nil {
+		return err
+	}
+
+	a := args[0].(*object.Integer).Value
+	b := args[1].(*object.Integer).Value
+	if a == 0 || b == 0 {
+		return &object.Integer{Value: 0}
+	}
+
+	divisor := gcdOf(a, b)
+	if divisor < 0 {
+		return newError("math.lcm: integer overflow")
+	}
+
+	result, ok := multiply(a/divisor, b)
+	if !ok || result == math.MinInt64 {
+		return newError("math.lcm: integer overflow")
+	}
+	if result < 0 {
+		result = -result
+	}
+
+	return &object.Integer{Value: result}
+}
+
+// clamp limits a number to lie between a lower and an upper bound
+func clamp(args ...object.Object) object.Object {
+	if _, err := numberArguments("math.clamp", args, 3); err != nil {
+		return err
+	}
+
+	x, low, high := args[0], args[1], args[2]
+	if compareNumbers(low, high) > 0 {
+		return newError("math.clamp: lower bound %s is greater than upper bound %s", low.Inspect(), high.Inspect())
+	}
+
+	switch {
+	case compareNumbers(x, low) < 0:
+		return low
+	case compareNumbers(x, high) > 0:
+		return high
+	default:
+		return x
+	}
+}
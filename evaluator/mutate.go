@@ -0,0 +1,130 @@
+package evaluator
+
+import "hummus-lang/object"
+
+func init() {
+	predefs["push"] = &object.Predef{Function: push}
+	predefs["pop"] = &object.Predef{Function: pop}
+	predefs["put"] = &object.Predef{Function: put}
+	predefs["add"] = &object.Predef{Function: add}
+	predefs["delete"] = &object.Predef{Function: deleteKey}
+}
+
+// checkMutable returns an error naming obj if it has been frozen
+func checkMutable(name string, obj object.Object) *object.Error {
+	frozen := false
+	switch x := obj.(type) {
+	case *object.Array:
+		frozen = x.Frozen
+	case *object.Hash:
+		frozen = x.Frozen
+	case *object.Set:
+		frozen = x.Frozen
+	}
+
+	if frozen {
+		return newError("%s: can not change %s, it is frozen", name, obj.Inspect())
+	}
+	return nil
+}
+
+// push adds values to the end of an array, returning the array
+func push(args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return newError("push: expected at least 2 arguments. given %d", len(args))
+	}
+
+	array, ok := args[0].(*object.Array)
+	if !ok {
+		return newError("push: argument 1 must be `ARRAY`, got `%s`", args[0].Type())
+	}
+	if err := checkMutable("push", array); err != nil {
+		return err
+	}
+
+	array.Elements = append(array.Elements, args[1:]...)
+	return array
+}
+
+// pop removes the last element of an array and returns it
+func pop(args ...object.Object) object.Object {
+	if err := checkArguments("pop", args, object.ArrayObj); err != nil {
+		return err
+	}
+
+	array := args[0].(*object.Array)
+	if err := checkMutable("pop", array); err != nil {
+		return err
+	}
+	if len(array.Elements) == 0 {
+		return newError("pop: can not pop from an empty array")
+	}
+
+	// cap what is left, so pushing afterwards can not write over the popped
+	// element in case something else still holds it
+	n := len(array.Elements) - 1
+	last := array.Elements[n]
+	array.Elements = array.Elements[:n:n]
+	return last
+}
+
+// put binds a key to a value in a hash, returning the hash
+func put(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("put: expected exactly 3 arguments. given %d", len(args))
+	}
+
+	hash, ok := args[0].(*object.Hash)
+	if !ok {
+		return newError("put: argument 1 must be `HASH`, got `%s`", args[0].Type())
+	}
+	if err := checkMutable("put", hash); err != nil {
+		return err
+	}
+
+	if !hash.Set(args[1], args[2]) {
+		return newError("put: can not use `%s` as a hash key", args[1].Type())
+	}
+	return hash
+}
+
+// add puts a value in a set, returning the set
+func add(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("add: expected exactly 2 arguments. given %d", len(args))
+	}
+
+	set, ok := args[0].(*object.Set)
+	if !ok {
+		return newError("add: argument 1 must be `SET`, got `%s`", args[0].Type())
+	}
+	if err := checkMutable("add", set); err != nil {
+		return err
+	}
+
+	if !set.Add(args[1]) {
+		return newError("add: can not use `%s` as a set element", args[1].Type())
+	}
+	return set
+}
+
+// deleteKey removes a key from a hash or an element from a set, returning
+// whether it was there
+func deleteKey(args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return newError("delete: expected exactly 2 arguments. given %d", len(args))
+	}
+
+	if err := checkMutable("delete", args[0]); err != nil {
+		return err
+	}
+
+	switch x := args[0].(type) {
+	case *object.Hash:
+		return nativeBoolToBooleanObject(x.Delete(args[1]))
+	case *object.Set:
+		return nativeBoolToBooleanObject(x.Delete(args[1]))
+	default:
+		return newError("delete: argument 1 must be `HASH` or `SET`, got `%s`", args[0].Type())
+	}
+}
@@ -0,0 +1,65 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"os"
+)
+
+func init() {
+	predefs["env"] = &object.Predef{Function: getEnv}
+	predefs["setEnv"] = &object.Predef{Function: setEnv}
+}
+
+// InstallProcess defines `args`, the arguments the script was given, and
+// `exit` in env. exit hands its status code to the host's exit function,
+// which is not expected to return
+func InstallProcess(env *object.Environment, args []string, exit func(code int)) {
+	env.Set("args", stringsToArray(args))
+	env.Set("exit", &object.Predef{Function: func(args ...object.Object) object.Object {
+		if len(args) > 1 {
+			return newError("exit: expected 0 or 1 arguments. given %d", len(args))
+		}
+
+		code := int64(0)
+		if len(args) == 1 {
+			if err := checkArguments("exit", args, object.IntegerObj); err != nil {
+				return err
+			}
+			code = args[0].(*object.Integer).Value
+		}
+
+		if code < 0 || code > 255 {
+			return newError("exit: status code must be between 0 and 255, got %d", code)
+		}
+
+		exit(int(code))
+		return Null
+	}})
+}
+
+// getEnv returns the value of an environment variable, or null if it is not
+// set
+func getEnv(args ...object.Object) object.Object {
+	if err := checkArguments("env", args, object.StringObj); err != nil {
+		return err
+	}
+
+	value, ok := os.LookupEnv(args[0].(*object.String).Value)
+	if !ok {
+		return Null
+	}
+
+	return &object.String{Value: value}
+}
+
+func setEnv(args ...object.Object) object.Object {
+	if err := checkArguments("setEnv", args, object.StringObj, object.StringObj); err != nil {
+		return err
+	}
+
+	if err := os.Setenv(args[0].(*object.String).Value, args[1].(*object.String).Value); err != nil {
+		return newError("setEnv: %v", err)
+	}
+
+	return Null
+}
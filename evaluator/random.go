@@ -0,0 +1,115 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"math"
+	"math/rand"
+)
+
+// randomSource is the random number generator of one interpreter, so that
+// scripts seeded with the same value make the same choices no matter what
+// else is running
+type randomSource struct {
+	rng *rand.Rand
+}
+
+// InstallRandom defines the `random` module in env, with its own generator
+// starting from seed. Scripts can reseed it with random.seed
+func InstallRandom(env *object.Environment, seed int64) {
+	r := &randomSource{rng: rand.New(rand.NewSource(seed))}
+
+	env.Set("random", &object.Module{
+		Name: "random",
+		Members: map[string]object.Object{
+			"int":     &object.Predef{Function: r.int},
+			"float":   &object.Predef{Function: r.float},
+			"choice":  &object.Predef{Function: r.choice},
+			"shuffle": &object.Predef{Function: r.shuffle},
+			"seed":    &object.Predef{Function: r.seed},
+		},
+	})
+}
+
+// int returns an integer between lo and hi, including both
+func (r *randomSource) int(args ...object.Object) object.Object {
+	if err := checkArguments("random.int", args, object.IntegerObj, object.IntegerObj); err != nil {
+		return err
+	}
+
+	lo, hi := args[0].(*object.Integer).Value, args[1].(*object.Integer).Value
+	if lo > hi {
+		return newError("random.int: lower bound %d is greater than upper bound %d", lo, hi)
+	}
+
+	span := uint64(hi) - uint64(lo)
+	if span < math.MaxInt64 {
+		return &object.Integer{Value: lo + r.rng.Int63n(int64(span)+1)}
+	}
+
+	// the range covers at least half of all integers, so this rarely takes
+	// more than a couple of tries
+	for {
+		if x := int64(r.rng.Uint64()); lo <= x && x <= hi {
+			return &object.Integer{Value: x}
+		}
+	}
+}
+
+// float returns a float that is at least 0 and less than 1
+func (r *randomSource) float(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("random.float: expected exactly 0 arguments. given %d", len(args))
+	}
+
+	return &object.Float{Value: r.rng.Float64()}
+}
+
+// choice picks one element of an array, tuple, set or string
+func (r *randomSource) choice(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("random.choice: expected exactly 1 argument. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("random.choice", args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(elements) == 0 {
+		return newError("random.choice: can not choose from an empty `%s`", args[0].Type())
+	}
+
+	return elements[r.rng.Intn(len(elements))]
+}
+
+// shuffle returns a new array holding the elements of an array, tuple, set or
+// string in a random order
+func (r *randomSource) shuffle(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("random.shuffle: expected exactly 1 argument. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("random.shuffle", args[0])
+	if err != nil {
+		return err
+	}
+
+	shuffled := make([]object.Object, len(elements))
+	copy(shuffled, elements)
+	r.rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	return &object.Array{Elements: shuffled}
+}
+
+// seed restarts the generator, so the numbers that follow are the same as
+// every other time it was given this seed
+func (r *randomSource) seed(args ...object.Object) object.Object {
+	if err := checkArguments("random.seed", args, object.IntegerObj); err != nil {
+		return err
+	}
+
+	r.rng.Seed(args[0].(*object.Integer).Value)
+	return Null
+}
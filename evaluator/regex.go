@@ -0,0 +1,249 @@
+package evaluator
+
+import (
+	"container/list"
+	"hummus-lang/ast"
+	"hummus-lang/object"
+	"strings"
+	"sync"
+)
+
+func init() {
+	modules["regex"] = &object.Module{
+		Name: "regex",
+		Members: map[string]object.Object{
+			"compile": &object.Predef{Function: compileRegex},
+			"match":   &object.Predef{Function: matchRegex},
+			"find":    &object.Predef{Function: findRegex},
+			"findAll": &object.Predef{Function: findAllRegex},
+			"replace": &object.Predef{Function: replaceRegex},
+			"split":   &object.Predef{Function: splitRegex},
+		},
+	}
+}
+
+// maxCompiledPatterns is how many patterns compiledPatterns remembers
+const maxCompiledPatterns = 64
+
+// compiledPatterns holds the regexes most recently compiled from patterns
+// given as strings, so a pattern used in a loop is only compiled once. It is
+// shared by every interpreter in the process
+var compiledPatterns = &patternCache{entries: map[string]*list.Element{}, recent: list.New()}
+
+// patternCache remembers a limited number of compiled regexes, forgetting the
+// least recently used one when it is full
+type patternCache struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element // patterns, and their place in recent
+	recent  *list.List               // the cached regexes, most recently used first
+}
+
+func (c *patternCache) get(pattern string) (*object.Regex, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[pattern]
+	if !ok {
+		return nil, false
+	}
+	c.recent.MoveToFront(e)
+	return e.Value.(*object.Regex), true
+}
+
+func (c *patternCache) put(regex *object.Regex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[regex.Pattern]; ok {
+		c.recent.MoveToFront(e)
+		return
+	}
+
+	c.entries[regex.Pattern] = c.recent.PushFront(regex)
+	if c.recent.Len() > maxCompiledPatterns {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.entries, oldest.Value.(*object.Regex).Pattern)
+	}
+}
+
+// compileRegex builds a regex from a pattern string and optional flags, the
+// same way a /pattern/flags literal would be
+func compileRegex(args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("regex.compile: expected 1 or 2 arguments. given %d", len(args))
+	}
+
+	types := []object.ObjectType{object.StringObj, object.StringObj}
+	if err := checkArguments("regex.compile", args, types[:len(args)]...); err != nil {
+		return err
+	}
+
+	pattern, flags := args[0].(*object.String).Value, ""
+	if len(args) == 2 {
+		flags = args[1].(*object.String).Value
+	}
+
+	compiled, err := ast.CompileRegex(pattern, flags)
+	if err != nil {
+		return newError("regex.compile: %v", err)
+	}
+
+	return &object.Regex{Pattern: pattern, Flags: flags, Regexp: compiled}
+}
+
+// regexArgument gets the regex a regex predef was given, compiling it if it
+// was given as a string
+func regexArgument(name string, arg object.Object) (*object.Regex, *object.Error) {
+	switch x := arg.(type) {
+	case *object.Regex:
+		return x, nil
+	case *object.String:
+		if cached, ok := compiledPatterns.get(x.Value); ok {
+			return cached, nil
+		}
+
+		compiled, err := ast.CompileRegex(x.Value, "")
+		if err != nil {
+			return nil, newError("%s: %v", name, err)
+		}
+
+		regex := &object.Regex{Pattern: x.Value, Regexp: compiled}
+		compiledPatterns.put(regex)
+		return regex, nil
+	default:
+		return nil, newError("%s: argument 1 must be `REGEX` or `STRING`, got `%s`", name, arg.Type())
+	}
+}
+
+// regexArguments checks a regex predef was given a pattern, a string to search
+// and the expected arguments after them
+func regexArguments(name string, args []object.Object, types ...object.ObjectType) (*object.Regex, string, *object.Error) {
+	if len(args) != len(types)+2 {
+		return nil, "", newError("%s: expected exactly %d arguments. given %d", name, len(types)+2, len(args))
+	}
+
+	regex, err := regexArgument(name, args[0])
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := checkArguments(name, args, append([]object.ObjectType{args[0].Type(), object.StringObj}, types...)...); err != nil {
+		return nil, "", err
+	}
+
+	return regex, args[1].(*object.String).Value, nil
+}
+
+// groups turns the indexes of a match into an array of the text matched and
+// the text of each capture group, with null for groups that did not take part
+func groups(s string, match []int) *object.Array {
+	elements := make([]object.Object, len(match)/2)
+	for i := range elements {
+		start, end := match[2*i], match[2*i+1]
+		if start < 0 {
+			elements[i] = Null
+		} else {
+			elements[i] = &object.String{Value: s[start:end]}
+		}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+func matchRegex(args ...object.Object) object.Object {
+	regex, s, err := regexArguments("regex.match", args)
+	if err != nil {
+		return err
+	}
+
+	return nativeBoolToBooleanObject(regex.Regexp.MatchString(s))
+}
+
+// findRegex returns the first match as an array of the matched text followed
+// by its capture groups, or null if there is no match
+func findRegex(args ...object.Object) object.Object {
+	regex, s, err := regexArguments("regex.find", args)
+	if err != nil {
+		return err
+	}
+
+	match := regex.Regexp.FindStringSubmatchIndex(s)
+	if match == nil {
+		return Null
+	}
+
+	return groups(s, match)
+}
+
+// findAllRegex returns every match, each one shaped like the result of find
+func findAllRegex(args ...object.Object) object.Object {
+	regex, s, err := regexArguments("regex.findAll", args)
+	if err != nil {
+		return err
+	}
+
+	matches := []object.Object{}
+	for _, match := range regex.Regexp.FindAllStringSubmatchIndex(s, -1) {
+		matches = append(matches, groups(s, match))
+	}
+
+	return &object.Array{Elements: matches}
+}
+
+// replaceRegex replaces every match. The replacement is either a string, in
+// which $1 or ${name} stand for capture groups, or a function that is given
+// the groups of each match as find would return them and returns the string to
+// put in its place
+func replaceRegex(args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return newError("regex.replace: expected exactly 3 arguments. given %d", len(args))
+	}
+
+	replacement := args[2]
+	switch replacement.(type) {
+	case *object.String, *object.Function, *object.Predef:
+	default:
+		return newError("regex.replace: argument 3 must be `STRING` or `FUNCTION`, got `%s`", replacement.Type())
+	}
+
+	regex, s, err := regexArguments("regex.replace", args, replacement.Type())
+	if err != nil {
+		return err
+	}
+
+	if template, ok := replacement.(*object.String); ok {
+		return &object.String{Value: regex.Regexp.ReplaceAllString(s, template.Value)}
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, match := range regex.Regexp.FindAllStringSubmatchIndex(s, -1) {
+		result := call(replacement, groups(s, match))
+		if isError(result) {
+			return result
+		}
+
+		str, ok := result.(*object.String)
+		if !ok {
+			return newError("regex.replace: replacement function must return `STRING`, got `%s`", result.Type())
+		}
+
+		out.WriteString(s[last:match[0]])
+		out.WriteString(str.Value)
+		last = match[1]
+	}
+	out.WriteString(s[last:])
+
+	return &object.String{Value: out.String()}
+}
+
+// splitRegex breaks a string up around every match
+func splitRegex(args ...object.Object) object.Object {
+	regex, s, err := regexArguments("regex.split", args)
+	if err != nil {
+		return err
+	}
+
+	return stringsToArray(regex.Regexp.Split(s, -1))
+}
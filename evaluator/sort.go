@@ -0,0 +1,247 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"sort"
+)
+
+func init() {
+	predefs["sort"] = &object.Predef{Function: sortElements}
+	predefs["sortBy"] = &object.Predef{Function: sortBy}
+	predefs["sortWith"] = &object.Predef{Function: sortWith}
+}
+
+// typeRanks orders values of different types when they are sorted together
+var typeRanks = map[object.ObjectType]int{
+	object.NullObj:     0,
+	object.BooleanObj:  1,
+	object.IntegerObj:  2,
+	object.FloatObj:    2,
+	object.StringObj:   3,
+	object.TupleObj:    4,
+	object.ArrayObj:    5,
+	object.TimeObj:     6,
+	object.DurationObj: 7,
+}
+
+// compareObjects returns a negative number, zero or a positive number as a
+// sorts before, alongside or after b. Values of different types are ordered
+// by type, though integers and floats compare as numbers. Tuples and arrays
+// are ordered element by element, and enum values by their overloaded `<`
+func compareObjects(a object.Object, b object.Object) (int, *object.Error) {
+	return compareValues(a, b, map[[2]object.Object]bool{})
+}
+
+// compareValues is compareObjects, remembering the pairs of arrays being
+// compared in visiting. Arrays can be changed to hold themselves, and meeting
+// a pair again means the comparison would never end
+func compareValues(a object.Object, b object.Object, visiting map[[2]object.Object]bool) (int, *object.Error) {
+	if handler, ok := operatorHandler("<", a, b); ok {
+		return compareWithLess(handler, a, b)
+	}
+
+	aRank, aOk := typeRanks[a.Type()]
+	bRank, bOk := typeRanks[b.Type()]
+	if !aOk || !bOk {
+		return 0, newError("can not compare `%s` and `%s`", a.Type(), b.Type())
+	}
+	if aRank != bRank {
+		return aRank - bRank, nil
+	}
+
+	switch a := a.(type) {
+	case *object.Integer, *object.Float:
+		return compareNumbers(a, b), nil
+
+	case *object.String:
+		b := b.(*object.String)
+		switch {
+		case a.Value < b.Value:
+			return -1, nil
+		case a.Value > b.Value:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case *object.Boolean:
+		b := b.(*object.Boolean)
+		switch {
+		case a.Value == b.Value:
+			return 0, nil
+		case b.Value:
+			return -1, nil
+		default:
+			return 1, nil
+		}
+
+	case *object.Time:
+		b := b.(*object.Time)
+		switch {
+		case a.Value.Before(b.Value):
+			return -1, nil
+		case a.Value.After(b.Value):
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case *object.Duration:
+		b := b.(*object.Duration)
+		switch {
+		case a.Value < b.Value:
+			return -1, nil
+		case a.Value > b.Value:
+			return 1, nil
+		default:
+			return 0, nil
+		}
+
+	case *object.Tuple:
+		return compareElements(a.Elements, b.(*object.Tuple).Elements, visiting)
+
+	case *object.Array:
+		if a == b {
+			return 0, nil
+		}
+
+		pair := [2]object.Object{a, b}
+		if visiting[pair] {
+			return 0, newError("can not compare values that contain themselves")
+		}
+		visiting[pair] = true
+		defer delete(visiting, pair)
+
+		return compareElements(a.Elements, b.(*object.Array).Elements, visiting)
+
+	default:
+		return 0, nil
+	}
+}
+
+func compareElements(a []object.Object, b []object.Object, visiting map[[2]object.Object]bool) (int, *object.Error) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		c, err := compareValues(a[i], b[i], visiting)
+		if err != nil || c != 0 {
+			return c, err
+		}
+	}
+
+	return len(a) - len(b), nil
+}
+
+func compareWithLess(less object.Object, a object.Object, b object.Object) (int, *object.Error) {
+	for _, pair := range [][]object.Object{{a, b}, {b, a}} {
+		result := call(less, pair...)
+		if err, ok := result.(*object.Error); ok {
+			return 0, err
+		}
+		if isTruthy(result) {
+			if pair[0] == a {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
+
+// sortStable works out the order of elements as a list of their indexes,
+// keeping equal elements in place. It gives up at the first error compare
+// reports
+func sortStable(elements []object.Object, compare func(i, j int) (int, *object.Error)) ([]int, *object.Error) {
+	order := make([]int, len(elements))
+	for i := range order {
+		order[i] = i
+	}
+
+	var failure *object.Error
+	sort.SliceStable(order, func(i, j int) bool {
+		if failure != nil {
+			return false
+		}
+
+		c, err := compare(order[i], order[j])
+		if err != nil {
+			failure = err
+			return false
+		}
+		return c < 0
+	})
+
+	return order, failure
+}
+
+func sortedArray(elements []object.Object, order []int) *object.Array {
+	sorted := make([]object.Object, len(order))
+	for i, o := range order {
+		sorted[i] = elements[o]
+	}
+
+	return &object.Array{Elements: sorted}
+}
+
+func sortElements(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("sort: expected exactly 1 argument. given %d", len(args))
+	}
+
+	elements, err := elementsArgument("sort", args[0])
+	if err != nil {
+		return err
+	}
+
+	order, err := sortStable(elements, func(i, j int) (int, *object.Error) {
+		return compareObjects(elements[i], elements[j])
+	})
+	if err != nil {
+		return err
+	}
+
+	return sortedArray(elements, order)
+}
+
+// sortBy sorts by the key fn gives each element, calling fn once per element
+var sortBy = withCallback("sortBy", func(elements []object.Object, fn object.Object) object.Object {
+	keys := make([]object.Object, len(elements))
+	for i, e := range elements {
+		key := call(fn, e)
+		if isError(key) {
+			return key
+		}
+		keys[i] = key
+	}
+
+	order, err := sortStable(elements, func(i, j int) (int, *object.Error) {
+		return compareObjects(keys[i], keys[j])
+	})
+	if err != nil {
+		return err
+	}
+
+	return sortedArray(elements, order)
+})
+
+// sortWith sorts using a comparator that returns a negative integer, zero or
+// a positive integer, as its first argument sorts before, alongside or after
+// its second
+var sortWith = withCallback("sortWith", func(elements []object.Object, fn object.Object) object.Object {
+	order, err := sortStable(elements, func(i, j int) (int, *object.Error) {
+		result := call(fn, elements[i], elements[j])
+		if err, ok := result.(*object.Error); ok {
+			return 0, err
+		}
+
+		c, ok := result.(*object.Integer)
+		if !ok {
+			return 0, newError("sortWith: comparator must return `INTEGER`, got `%s`", result.Type())
+		}
+		return int(c.Value), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return sortedArray(elements, order)
+})
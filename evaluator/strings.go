@@ -0,0 +1,244 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	predefs["split"] = &object.Predef{Function: split}
+	predefs["join"] = &object.Predef{Function: join}
+	predefs["trim"] = &object.Predef{Function: stringFunction("trim", strings.TrimSpace)}
+	predefs["trimLeft"] = &object.Predef{Function: stringFunction("trimLeft", func(s string) string {
+		return strings.TrimLeftFunc(s, unicode.IsSpace)
+	})}
+	predefs["trimRight"] = &object.Predef{Function: stringFunction("trimRight", func(s string) string {
+		return strings.TrimRightFunc(s, unicode.IsSpace)
+	})}
+	predefs["upper"] = &object.Predef{Function: stringFunction("upper", strings.ToUpper)}
+	predefs["lower"] = &object.Predef{Function: stringFunction("lower", strings.ToLower)}
+	predefs["replace"] = &object.Predef{Function: replace}
+	predefs["startsWith"] = &object.Predef{Function: stringTest("startsWith", strings.HasPrefix)}
+	predefs["endsWith"] = &object.Predef{Function: stringTest("endsWith", strings.HasSuffix)}
+	predefs["indexOf"] = &object.Predef{Function: indexOf}
+	predefs["repeat"] = &object.Predef{Function: repeat}
+	predefs["padLeft"] = &object.Predef{Function: pad("padLeft", func(s string, padding string) string {
+		return padding + s
+	})}
+	predefs["padRight"] = &object.Predef{Function: pad("padRight", func(s string, padding string) string {
+		return s + padding
+	})}
+	predefs["chars"] = &object.Predef{Function: chars}
+	predefs["reverse"] = &object.Predef{Function: reverse}
+}
+
+// checkArguments makes sure a predef was given exactly as many arguments as
+// there are types, each of the matching type
+func checkArguments(name string, args []object.Object, types ...object.ObjectType) *object.Error {
+	if len(args) != len(types) {
+		if len(types) == 1 {
+			return newError("%s: expected exactly 1 argument. given %d", name, len(args))
+		}
+		return newError("%s: expected exactly %d arguments. given %d", name, len(types), len(args))
+	}
+
+	for i, t := range types {
+		if args[i].Type() != t {
+			return newError("%s: argument %d must be `%s`, got `%s`", name, i+1, t, args[i].Type())
+		}
+	}
+
+	return nil
+}
+
+// stringFunction builds a predef that transforms a single string
+func stringFunction(name string, f func(string) string) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if err := checkArguments(name, args, object.StringObj); err != nil {
+			return err
+		}
+
+		return &object.String{Value: f(args[0].(*object.String).Value)}
+	}
+}
+
+// stringTest builds a predef that asks a question about a pair of strings
+func stringTest(name string, f func(string, string) bool) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if err := checkArguments(name, args, object.StringObj, object.StringObj); err != nil {
+			return err
+		}
+
+		return nativeBoolToBooleanObject(f(args[0].(*object.String).Value, args[1].(*object.String).Value))
+	}
+}
+
+func stringsToArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+
+	return &object.Array{Elements: elements}
+}
+
+// split breaks a string up around every separator. An empty separator splits
+// the string into its characters
+func split(args ...object.Object) object.Object {
+	if err := checkArguments("split", args, object.StringObj, object.StringObj); err != nil {
+		return err
+	}
+
+	return stringsToArray(strings.Split(args[0].(*object.String).Value, args[1].(*object.String).Value))
+}
+
+func join(args ...object.Object) object.Object {
+	if err := checkArguments("join", args, object.ArrayObj, object.StringObj); err != nil {
+		return err
+	}
+
+	values := []string{}
+	for _, e := range args[0].(*object.Array).Elements {
+		s, ok := e.(*object.String)
+		if !ok {
+			return newError("join: can only join strings, got `%s`", e.Type())
+		}
+		values = append(values, s.Value)
+	}
+
+	return &object.String{Value: strings.Join(values, args[1].(*object.String).Value)}
+}
+
+func replace(args ...object.Object) object.Object {
+	if err := checkArguments("replace", args, object.StringObj, object.StringObj, object.StringObj); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	old := args[1].(*object.String).Value
+	replacement := args[2].(*object.String).Value
+
+	return &object.String{Value: strings.ReplaceAll(s, old, replacement)}
+}
+
+// indexOf finds the position in characters of the first occurrence of a
+// substring, or -1 if there is none
+func indexOf(args ...object.Object) object.Object {
+	if err := checkArguments("indexOf", args, object.StringObj, object.StringObj); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	i := strings.Index(s, args[1].(*object.String).Value)
+	if i < 0 {
+		return &object.Integer{Value: -1}
+	}
+
+	return &object.Integer{Value: int64(utf8.RuneCountInString(s[:i]))}
+}
+
+// maxStringLength is the longest string, in bytes, repeat will build, and
+// the widest the pad predefs will pad to, so a mistaken count fails rather
+// than exhausting memory
+const maxStringLength = 1 << 24
+
+func repeat(args ...object.Object) object.Object {
+	if err := checkArguments("repeat", args, object.StringObj, object.IntegerObj); err != nil {
+		return err
+	}
+
+	s := args[0].(*object.String).Value
+	count := args[1].(*object.Integer).Value
+	if count < 0 {
+		return newError("repeat: count must not be negative. given %d", count)
+	}
+	if len(s) > 0 && count > maxStringLength/int64(len(s)) {
+		return newError("repeat: result would be longer than %d bytes", maxStringLength)
+	}
+
+	return &object.String{Value: strings.Repeat(s, int(count))}
+}
+
+// pad builds a predef that pads a string out to a width in characters, with
+// spaces or with the optional third argument
+func pad(name string, f func(s string, padding string) string) object.PredefFunction {
+	return func(args ...object.Object) object.Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("%s: expected 2 or 3 arguments. given %d", name, len(args))
+		}
+
+		var err *object.Error
+		if len(args) == 3 {
+			err = checkArguments(name, args, object.StringObj, object.IntegerObj, object.StringObj)
+		} else {
+			err = checkArguments(name, args, object.StringObj, object.IntegerObj)
+		}
+		if err != nil {
+			return err
+		}
+
+		s := args[0].(*object.String).Value
+		width := args[1].(*object.Integer).Value
+		if width > maxStringLength {
+			return newError("%s: width must not be more than %d. given %d", name, maxStringLength, width)
+		}
+
+		filler := []rune(" ")
+		if len(args) == 3 {
+			filler = []rune(args[2].(*object.String).Value)
+			if len(filler) == 0 {
+				return newError("%s: padding must not be empty", name)
+			}
+		}
+
+		missing := int(width) - utf8.RuneCountInString(s)
+		if missing <= 0 {
+			return &object.String{Value: s}
+		}
+
+		padding := make([]rune, missing)
+		for i := range padding {
+			padding[i] = filler[i%len(filler)]
+		}
+
+		return &object.String{Value: f(s, string(padding))}
+	}
+}
+
+func chars(args ...object.Object) object.Object {
+	if err := checkArguments("chars", args, object.StringObj); err != nil {
+		return err
+	}
+
+	values := []string{}
+	for _, r := range args[0].(*object.String).Value {
+		values = append(values, string(r))
+	}
+
+	return stringsToArray(values)
+}
+
+func reverse(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return newError("reverse: expected exactly 1 argument. given %d", len(args))
+	}
+
+	switch x := args[0].(type) {
+	case *object.String:
+		runes := []rune(x.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return &object.String{Value: string(runes)}
+	case *object.Array:
+		elements := make([]object.Object, len(x.Elements))
+		for i, e := range x.Elements {
+			elements[len(elements)-1-i] = e
+		}
+		return &object.Array{Elements: elements}
+	default:
+		return newError("reverse: can not reverse `%s`", args[0].Type())
+	}
+}
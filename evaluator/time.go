@@ -0,0 +1,320 @@
+package evaluator
+
+import (
+	"hummus-lang/object"
+	"math"
+	"time"
+)
+
+func init() {
+	modules["time"] = &object.Module{
+		Name: "time",
+		Members: map[string]object.Object{
+			"NANOSECOND":  &object.Duration{Value: time.Nanosecond},
+			"MICROSECOND": &object.Duration{Value: time.Microsecond},
+			"MILLISECOND": &object.Duration{Value: time.Millisecond},
+			"SECOND":      &object.Duration{Value: time.Second},
+			"MINUTE":      &object.Duration{Value: time.Minute},
+			"HOUR":        &object.Duration{Value: time.Hour},
+
+			"RFC3339":  &object.String{Value: time.RFC3339},
+			"RFC1123":  &object.String{Value: time.RFC1123},
+			"DATE":     &object.String{Value: "2006-01-02"},
+			"DATETIME": &object.String{Value: "2006-01-02 15:04:05"},
+
+			"now":      &object.Predef{Function: now},
+			"date":     &object.Predef{Function: date},
+			"parse":    &object.Predef{Function: parseTime},
+			"format":   &object.Predef{Function: formatTime},
+			"inZone":   &object.Predef{Function: inZone},
+			"zone":     &object.Predef{Function: zone},
+			"unix":     &object.Predef{Function: unix},
+			"fromUnix": &object.Predef{Function: fromUnix},
+			"parts":    &object.Predef{Function: timeParts},
+			"duration": &object.Predef{Function: parseDuration},
+			"seconds":  &object.Predef{Function: seconds},
+		},
+	}
+}
+
+// loadZone finds a time zone by its name in the system's time zone database,
+// such as "Europe/Paris". "UTC" and "Local" are always available
+func loadZone(name string, zone string) (*time.Location, *object.Error) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, newError("%s: unknown time zone %q", name, zone)
+	}
+	return location, nil
+}
+
+func now(args ...object.Object) object.Object {
+	if len(args) != 0 {
+		return newError("time.now: expected exactly 0 arguments. given %d", len(args))
+	}
+
+	return &object.Time{Value: time.Now()}
+}
+
+// date builds a time out of a year, month and day, optionally followed by an
+// hour, minute and second, and then by a time zone. Times are in UTC unless a
+// zone is given
+func date(args ...object.Object) object.Object {
+	location := time.UTC
+	if len(args) == 4 || len(args) == 7 {
+		name, ok := args[len(args)-1].(*object.String)
+		if !ok {
+			return newError("time.date: argument %d must be `STRING`, got `%s`", len(args), args[len(args)-1].Type())
+		}
+
+		var err *object.Error
+		if location, err = loadZone("time.date", name.Value); err != nil {
+			return err
+		}
+		args = args[:len(args)-1]
+	}
+
+	if len(args) != 3 && len(args) != 6 {
+		return newError("time.date: expected between 3 and 7 arguments. given %d", len(args))
+	}
+
+	fields := make([]int, 6)
+	for i, a := range args {
+		integer, ok := a.(*object.Integer)
+		if !ok {
+			return newError("time.date: argument %d must be `INTEGER`, got `%s`", i+1, a.Type())
+		}
+		fields[i] = int(integer.Value)
+	}
+
+	return &object.Time{Value: time.Date(fields[0], time.Month(fields[1]), fields[2], fields[3], fields[4], fields[5], 0, location)}
+}
+
+// parseTime reads a time written in the given layout, which is Go's: the way
+// Mon Jan 2 15:04:05 MST 2006 would be written. Times without a zone of their
+// own are taken to be in UTC, or in the zone given as a third argument
+func parseTime(args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return newError("time.parse: expected 2 or 3 arguments. given %d", len(args))
+	}
+
+	types := []object.ObjectType{object.StringObj, object.StringObj, object.StringObj}
+	if err := checkArguments("time.parse", args, types[:len(args)]...); err != nil {
+		return err
+	}
+
+	location := time.UTC
+	if len(args) == 3 {
+		var err *object.Error
+		if location, err = loadZone("time.parse", args[2].(*object.String).Value); err != nil {
+			return err
+		}
+	}
+
+	t, err := time.ParseInLocation(args[0].(*object.String).Value, args[1].(*object.String).Value, location)
+	if err != nil {
+		return newError("time.parse: %v", err)
+	}
+
+	return &object.Time{Value: t}
+}
+
+func formatTime(args ...object.Object) object.Object {
+	if err := checkArguments("time.format", args, object.TimeObj, object.StringObj); err != nil {
+		return err
+	}
+
+	return &object.String{Value: args[0].(*object.Time).Value.Format(args[1].(*object.String).Value)}
+}
+
+// inZone returns the same instant as it would be shown in another time zone
+func inZone(args ...object.Object) object.Object {
+	if err := checkArguments("time.inZone", args, object.TimeObj, object.StringObj); err != nil {
+		return err
+	}
+
+	location, err := loadZone("time.inZone", args[1].(*object.String).Value)
+	if err != nil {
+		return err
+	}
+
+	return &object.Time{Value: args[0].(*object.Time).Value.In(location)}
+}
+
+func zone(args ...object.Object) object.Object {
+	if err := checkArguments("time.zone", args, object.TimeObj); err != nil {
+		return err
+	}
+
+	return &object.String{Value: args[0].(*object.Time).Value.Location().String()}
+}
+
+// unix returns the number of seconds since the start of 1970 in UTC
+func unix(args ...object.Object) object.Object {
+	if err := checkArguments("time.unix", args, object.TimeObj); err != nil {
+		return err
+	}
+
+	return &object.Integer{Value: args[0].(*object.Time).Value.Unix()}
+}
+
+func fromUnix(args ...object.Object) object.Object {
+	if err := checkArguments("time.fromUnix", args, object.IntegerObj); err != nil {
+		return err
+	}
+
+	return &object.Time{Value: time.Unix(args[0].(*object.Integer).Value, 0).UTC()}
+}
+
+// timeParts breaks a time up into a hash of its year, month, day and so on,
+// as they are in its time zone
+func timeParts(args ...object.Object) object.Object {
+	if err := checkArguments("time.parts", args, object.TimeObj); err != nil {
+		return err
+	}
+
+	t := args[0].(*object.Time).Value
+	parts := object.NewHash()
+	for _, part := range []struct {
+		name  string
+		value int
+	}{
+		{"year", t.Year()},
+		{"month", int(t.Month())},
+		{"day", t.Day()},
+		{"hour", t.Hour()},
+		{"minute", t.Minute()},
+		{"second", t.Second()},
+		{"nanosecond", t.Nanosecond()},
+		{"yearDay", t.YearDay()},
+	} {
+		parts.Set(&object.String{Value: part.name}, &object.Integer{Value: int64(part.value)})
+	}
+	parts.Set(&object.String{Value: "weekday"}, &object.String{Value: t.Weekday().String()})
+
+	return parts
+}
+
+// parseDuration reads a duration such as "1h30m" or "-1.5s"
+func parseDuration(args ...object.Object) object.Object {
+	if err := checkArguments("time.duration", args, object.StringObj); err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(args[0].(*object.String).Value)
+	if err != nil {
+		return newError("time.duration: %v", err)
+	}
+
+	return &object.Duration{Value: d}
+}
+
+func seconds(args ...object.Object) object.Object {
+	if err := checkArguments("time.seconds", args, object.DurationObj); err != nil {
+		return err
+	}
+
+	return &object.Float{Value: args[0].(*object.Duration).Value.Seconds()}
+}
+
+func isTimeArithmetic(left object.Object, right object.Object) bool {
+	for _, obj := range []object.Object{left, right} {
+		if obj.Type() == object.TimeObj || obj.Type() == object.DurationObj {
+			return true
+		}
+	}
+	return false
+}
+
+// evalTimeInfixExpression works out arithmetic and comparisons on times and
+// durations. Subtracting two times gives the duration between them, durations
+// can be added to or subtracted from times, and durations can be multiplied or
+// divided by numbers
+func evalTimeInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Time:
+		switch right := right.(type) {
+		case *object.Time:
+			switch operator {
+			case "-":
+				return &object.Duration{Value: left.Value.Sub(right.Value)}
+			case "<":
+				return nativeBoolToBooleanObject(left.Value.Before(right.Value))
+			case ">":
+				return nativeBoolToBooleanObject(left.Value.After(right.Value))
+			}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Time{Value: left.Value.Add(right.Value)}
+			case "-":
+				return &object.Time{Value: left.Value.Add(-right.Value)}
+			}
+		}
+
+	case *object.Duration:
+		switch right := right.(type) {
+		case *object.Time:
+			if operator == "+" {
+				return &object.Time{Value: right.Value.Add(left.Value)}
+			}
+		case *object.Duration:
+			switch operator {
+			case "+":
+				return &object.Duration{Value: left.Value + right.Value}
+			case "-":
+				return &object.Duration{Value: left.Value - right.Value}
+			case "/":
+				if right.Value == 0 {
+					return newError("division by zero")
+				}
+				return &object.Integer{Value: int64(left.Value / right.Value)}
+			case "%":
+				if right.Value == 0 {
+					return newError("division by zero")
+				}
+				return &object.Duration{Value: left.Value % right.Value}
+			case "<":
+				return nativeBoolToBooleanObject(left.Value < right.Value)
+			case ">":
+				return nativeBoolToBooleanObject(left.Value > right.Value)
+			}
+		default:
+			if _, ok := floatValue(right); ok && (operator == "*" || operator == "/") {
+				return scaleDuration(operator, left, right)
+			}
+		}
+
+	default:
+		d, ok := right.(*object.Duration)
+		if _, isNumber := floatValue(left); ok && isNumber && operator == "*" {
+			return scaleDuration(operator, d, left)
+		}
+	}
+
+	if left.Type() != right.Type() {
+		return newError("type mismatch: can not %s `%s` and `%s`", operator, left.Type(), right.Type())
+	}
+	return newError("unknown operator: binary %s not defined for `%s` and `%s`", operator, left.Type(), right.Type())
+}
+
+// scaleDuration multiplies or divides a duration by a number. Dividing by an
+// integer is exact, like integer division
+func scaleDuration(operator string, d *object.Duration, number object.Object) object.Object {
+	factor, _ := floatValue(number)
+	if operator == "/" {
+		if factor == 0 {
+			return newError("division by zero")
+		}
+		if integer, ok := number.(*object.Integer); ok {
+			return &object.Duration{Value: d.Value / time.Duration(integer.Value)}
+		}
+		factor = 1 / factor
+	}
+
+	scaled := math.Round(float64(d.Value) * factor)
+	if math.IsNaN(scaled) || scaled < math.MinInt64 || scaled >= math.MaxInt64 {
+		return newError("duration overflow")
+	}
+
+	return &object.Duration{Value: time.Duration(scaled)}
+}
@@ -0,0 +1,507 @@
+// Package infer implements Hindley-Milner type inference for hummus programs.
+//
+// Unlike the checker package, which only trusts annotations, inference works
+// out a type for every expression and reports where they can not be made to
+// agree. It is opt-in: perfectly valid dynamic programs, e.g. ones mixing
+// types in an array, are rejected by it.
+package infer
+
+import (
+	"fmt"
+	"hummus-lang/ast"
+)
+
+// predefs maps the predefined functions to their types. Each function is
+// given fresh type variables to build the type with every time it is used
+var predefs = map[string]func(a, b Type) Type{
+	"len":    func(a, b Type) Type { return function([]Type{arrayOf(a)}, Int) },
+	"head":   func(a, b Type) Type { return function([]Type{arrayOf(a)}, a) },
+	"tail":   func(a, b Type) Type { return function([]Type{arrayOf(a)}, arrayOf(a)) },
+	"freeze": func(a, b Type) Type { return function([]Type{a}, a) },
+}
+
+// variadic describes a predefined function taking any number of arguments,
+// which no function type can. Only its first argument, if first is set, and
+// its result are checked
+type variadic struct {
+	first  Type
+	result Type
+}
+
+var variadicPredefs = map[string]variadic{
+	"print":     {result: Null},
+	"printLine": {result: Null},
+	"printf":    {first: String, result: Null},
+	"format":    {first: String, result: String},
+}
+
+type Error struct {
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string { return fmt.Sprintf("line %d: %s", e.Line, e.Message) }
+
+// Result holds the types inferred for a program
+type Result struct {
+	types map[ast.Node]Type
+}
+
+// TypeOf returns the inferred type of an expression in the program, or of an
+// identifier declared by a let statement or as a function parameter
+func (r *Result) TypeOf(node ast.Node) (Type, bool) {
+	t, ok := r.types[node]
+	if !ok {
+		return nil, false
+	}
+	return prune(t), true
+}
+
+type scope struct {
+	bindings map[string]*Scheme
+	outer    *scope
+}
+
+func newScope(outer *scope) *scope {
+	return &scope{bindings: make(map[string]*Scheme), outer: outer}
+}
+
+func (s *scope) get(name string) (*Scheme, bool) {
+	scheme, ok := s.bindings[name]
+	if !ok && s.outer != nil {
+		scheme, ok = s.outer.get(name)
+	}
+	return scheme, ok
+}
+
+type inferer struct {
+	nextID int
+
+	// level is the let-nesting depth. Variables created deeper than the
+	// current level are free to be generalized when a let binding finishes
+	level int
+
+	errors []*Error
+	types  map[ast.Node]Type
+
+	// return types of the functions currently being inferred
+	returns []Type
+}
+
+// Infer works out the types in program. Type errors are returned in the
+// order they were found; the result is still usable when there are some
+func Infer(program *ast.Program) (*Result, []*Error) {
+	in := &inferer{errors: []*Error{}, types: make(map[ast.Node]Type)}
+
+	s := newScope(nil)
+	for _, stmt := range program.Statements {
+		in.inferStatement(stmt, s)
+	}
+
+	return &Result{types: in.types}, in.errors
+}
+
+func (in *inferer) addError(line int, format string, a ...interface{}) {
+	in.errors = append(in.errors, &Error{Line: line, Message: fmt.Sprintf(format, a...)})
+}
+
+func (in *inferer) newVariable() *Variable {
+	in.nextID++
+	return &Variable{id: in.nextID, level: in.level}
+}
+
+func (in *inferer) inferStatement(stmt ast.Statement, s *scope) Type {
+	switch stmt := stmt.(type) {
+	case *ast.LetStatement:
+		in.inferLetStatement(stmt, s)
+		return Null
+
+	case *ast.ReturnStatement:
+		t := in.inferExpression(stmt.ReturnValue, s)
+		if len(in.returns) > 0 {
+			in.unify(t, in.returns[len(in.returns)-1], stmt.Token.Line)
+		}
+		// control never carries on past a return, so it fits anywhere
+		return in.newVariable()
+
+	case *ast.ExpressionStatement:
+		return in.inferExpression(stmt.Expression, s)
+
+	default:
+		return in.newVariable()
+	}
+}
+
+func (in *inferer) inferLetStatement(stmt *ast.LetStatement, s *scope) {
+	line := stmt.Token.Line
+
+	in.level++
+
+	// functions are bound before their body is inferred so they can call
+	// themselves. Within their own body they are not polymorphic
+	var self *Variable
+	if _, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		self = in.newVariable()
+		s.bindings[stmt.Name.Value] = &Scheme{Type: self}
+	}
+
+	t := in.inferExpression(stmt.Value, s)
+	if self != nil {
+		in.unify(self, t, line)
+	}
+	if stmt.Name.Type != nil {
+		in.unify(t, in.fromAnnotation(stmt.Name.Type), line)
+	}
+
+	in.level--
+
+	s.bindings[stmt.Name.Value] = in.generalize(t)
+	in.types[stmt.Name] = t
+}
+
+func (in *inferer) inferBlock(block *ast.BlockStatement, s *scope) Type {
+	var result Type = Null
+
+	for _, stmt := range block.Statements {
+		result = in.inferStatement(stmt, s)
+	}
+
+	return result
+}
+
+func (in *inferer) inferExpression(exp ast.Expression, s *scope) Type {
+	t := in.inferExpressionType(exp, s)
+	if exp != nil {
+		in.types[exp] = t
+	}
+	return t
+}
+
+func (in *inferer) inferExpressionType(exp ast.Expression, s *scope) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+
+	case *ast.StringLiteral:
+		return String
+
+	case *ast.Boolean:
+		return Bool
+
+	case *ast.NullLiteral:
+		return Null
+
+	case *ast.Identifier:
+		if scheme, ok := s.get(exp.Value); ok {
+			return in.instantiate(scheme)
+		}
+		if predef, ok := predefs[exp.Value]; ok {
+			return predef(in.newVariable(), in.newVariable())
+		}
+		// variadic predefs are only checked where they are called directly
+		// leave unknown references to the evaluator, which reports them
+		return in.newVariable()
+
+	case *ast.PrefixExpression:
+		right := in.inferExpression(exp.Right, s)
+		switch exp.Operator {
+		case "-", "~":
+			in.unify(right, Int, exp.Token.Line)
+			return Int
+		case "!":
+			return Bool
+		default:
+			return in.newVariable()
+		}
+
+	case *ast.InfixExpression:
+		return in.inferInfixExpression(exp, s)
+
+	case *ast.IfExpression:
+		in.inferExpression(exp.Condition, s)
+
+		consequence := in.inferBlock(exp.Consequence, s)
+		if exp.Alternative == nil {
+			return Null
+		}
+		alternative := in.inferBlock(exp.Alternative, s)
+
+		in.unify(consequence, alternative, exp.Token.Line)
+		return consequence
+
+	case *ast.FunctionLiteral:
+		return in.inferFunctionLiteral(exp, s)
+
+	case *ast.CallExpression:
+		callee := in.inferExpression(exp.Function, s)
+
+		args := []Type{}
+		for _, a := range exp.Arguments {
+			args = append(args, in.inferExpression(a, s))
+		}
+
+		if predef, ok := in.variadicPredef(exp.Function, s); ok {
+			if predef.first != nil && len(args) > 0 {
+				in.unify(args[0], predef.first, exp.Token.Line)
+			}
+			return predef.result
+		}
+
+		ret := in.newVariable()
+		in.unify(callee, function(args, ret), exp.Token.Line)
+		return ret
+
+	case *ast.ArrayLiteral:
+		element := in.newVariable()
+		for _, e := range exp.Elements {
+			in.unify(element, in.inferExpression(e, s), exp.Token.Line)
+		}
+		return arrayOf(element)
+
+	case *ast.IndexExpression:
+		left := in.inferExpression(exp.Left, s)
+		index := in.inferExpression(exp.Right, s)
+
+		in.unify(index, Int, exp.Token.Line)
+
+		if prune(left) == String {
+			return String
+		}
+
+		element := in.newVariable()
+		in.unify(left, arrayOf(element), exp.Token.Line)
+		return element
+
+	default:
+		return in.newVariable()
+	}
+}
+
+// variadicPredef reports whether callee names a variadic predef that has not
+// been shadowed by a binding of the same name
+func (in *inferer) variadicPredef(callee ast.Expression, s *scope) (variadic, bool) {
+	ident, ok := callee.(*ast.Identifier)
+	if !ok {
+		return variadic{}, false
+	}
+	if _, shadowed := s.get(ident.Value); shadowed {
+		return variadic{}, false
+	}
+
+	predef, ok := variadicPredefs[ident.Value]
+	return predef, ok
+}
+
+func (in *inferer) inferInfixExpression(exp *ast.InfixExpression, s *scope) Type {
+	left := in.inferExpression(exp.Left, s)
+	right := in.inferExpression(exp.Right, s)
+	line := exp.Token.Line
+
+	switch exp.Operator {
+	case "+":
+		in.unify(left, right, line)
+		if c, ok := prune(left).(*Constructor); ok && c != Int && c != String {
+			in.addError(line, "can not + %s", c)
+		}
+		return left
+	case "-", "*", "/", "%", "&", "|", "^", "<<", ">>":
+		in.unify(left, Int, line)
+		in.unify(right, Int, line)
+		return Int
+	case "<", ">":
+		in.unify(left, Int, line)
+		in.unify(right, Int, line)
+		return Bool
+	case "==", "!=":
+		in.unify(left, right, line)
+		return Bool
+	default:
+		return in.newVariable()
+	}
+}
+
+func (in *inferer) inferFunctionLiteral(fn *ast.FunctionLiteral, s *scope) Type {
+	inner := newScope(s)
+
+	params := []Type{}
+	for _, p := range fn.Parameters {
+		var t Type = in.newVariable()
+		if p.Type != nil {
+			t = in.fromAnnotation(p.Type)
+		}
+
+		inner.bindings[p.Value] = &Scheme{Type: t}
+		in.types[p] = t
+		params = append(params, t)
+	}
+
+	ret := in.newVariable()
+	if fn.ReturnType != nil {
+		in.unify(ret, in.fromAnnotation(fn.ReturnType), fn.Token.Line)
+	}
+
+	in.returns = append(in.returns, ret)
+	body := in.inferBlock(fn.Body, inner)
+	in.returns = in.returns[:len(in.returns)-1]
+
+	line := fn.Token.Line
+	if statements := fn.Body.Statements; len(statements) > 0 {
+		if last, ok := statements[len(statements)-1].(*ast.ExpressionStatement); ok {
+			line = last.Token.Line
+		}
+	}
+	in.unify(body, ret, line)
+
+	return function(params, ret)
+}
+
+func (in *inferer) fromAnnotation(annotation *ast.TypeAnnotation) Type {
+	switch annotation.Name {
+	case "int":
+		return Int
+	case "string":
+		return String
+	case "bool":
+		return Bool
+	case "null":
+		return Null
+	case "[]":
+		return arrayOf(in.fromAnnotation(annotation.Element))
+	case "fn":
+		params := []Type{}
+		for _, p := range annotation.Parameters {
+			params = append(params, in.fromAnnotation(p))
+		}
+		return function(params, in.fromAnnotation(annotation.Return))
+	default:
+		return in.newVariable()
+	}
+}
+
+// unify makes a and b the same type, reporting an error on line if they can
+// not be
+func (in *inferer) unify(a Type, b Type, line int) {
+	if !in.unifyTypes(a, b) {
+		names := map[*Variable]string{}
+		in.addError(line, "can not unify %s with %s", typeString(a, names), typeString(b, names))
+	}
+}
+
+func (in *inferer) unifyTypes(a Type, b Type) bool {
+	a, b = prune(a), prune(b)
+
+	if av, ok := a.(*Variable); ok {
+		if a == b {
+			return true
+		}
+		if occursIn(av, b) {
+			return false
+		}
+		adjustLevels(b, av.level)
+		av.Instance = b
+		return true
+	}
+
+	if _, ok := b.(*Variable); ok {
+		return in.unifyTypes(b, a)
+	}
+
+	ac, bc := a.(*Constructor), b.(*Constructor)
+	if ac.Name != bc.Name || len(ac.Args) != len(bc.Args) {
+		return false
+	}
+
+	for i := range ac.Args {
+		if !in.unifyTypes(ac.Args[i], bc.Args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func occursIn(v *Variable, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Variable:
+		return t == v
+	case *Constructor:
+		for _, arg := range t.Args {
+			if occursIn(v, arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// adjustLevels stops variables in t from being generalized any further out
+// than level, since they are now tied to a variable that lives there
+func adjustLevels(t Type, level int) {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if t.level > level {
+			t.level = level
+		}
+	case *Constructor:
+		for _, arg := range t.Args {
+			adjustLevels(arg, level)
+		}
+	}
+}
+
+func (in *inferer) generalize(t Type) *Scheme {
+	scheme := &Scheme{Type: t}
+	seen := map[*Variable]bool{}
+
+	var collect func(t Type)
+	collect = func(t Type) {
+		switch t := prune(t).(type) {
+		case *Variable:
+			if t.level > in.level && !seen[t] {
+				seen[t] = true
+				scheme.Quantified = append(scheme.Quantified, t)
+			}
+		case *Constructor:
+			for _, arg := range t.Args {
+				collect(arg)
+			}
+		}
+	}
+	collect(t)
+
+	return scheme
+}
+
+func (in *inferer) instantiate(scheme *Scheme) Type {
+	if len(scheme.Quantified) == 0 {
+		return scheme.Type
+	}
+
+	fresh := map[*Variable]Type{}
+	for _, v := range scheme.Quantified {
+		fresh[v] = in.newVariable()
+	}
+
+	var copyType func(t Type) Type
+	copyType = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *Variable:
+			if f, ok := fresh[t]; ok {
+				return f
+			}
+			return t
+		case *Constructor:
+			if len(t.Args) == 0 {
+				return t
+			}
+			args := []Type{}
+			for _, arg := range t.Args {
+				args = append(args, copyType(arg))
+			}
+			return &Constructor{Name: t.Name, Args: args}
+		}
+		return t
+	}
+
+	return copyType(scheme.Type)
+}
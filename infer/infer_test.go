@@ -0,0 +1,114 @@
+package infer
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"hummus-lang/ast"
+	"hummus-lang/lexer"
+	"hummus-lang/parser"
+	"io/ioutil"
+	"testing"
+)
+
+func TestInferLetBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let x = 5;`, "int"},
+		{`let xs = ["a", "b"];`, "[string]"},
+		{`let id = fn(x) { x };`, "a -> a"},
+		{`let double = fn(x) { x * 2 };`, "int -> int"},
+		{`let add = (a, b) => a + b;`, "(a, a) -> a"},
+		{`let twice = fn(f, x) { f(f(x)) };`, "(a -> a, a) -> a"},
+		{`let fact = fn(n) { if (n == 0) { return 1; } n * fact(n - 1) };`, "int -> int"},
+		{`let size = fn(xs) { len(xs) };`, "[a] -> int"},
+		{`let first = fn(s: string) { s[0] };`, "string -> string"},
+		{`let greet = fn(name) -> string { return name; };`, "string -> string"},
+		{`let constant = fn(x) { fn(y) { x } };`, "a -> b -> a"},
+	}
+
+	for idx, tt := range tests {
+		program := parse(t, tt.input)
+
+		result, errors := Infer(program)
+		require.Emptyf(t, errors, "test case %d failed", idx)
+
+		let := program.Statements[0].(*ast.LetStatement)
+		inferred, ok := result.TypeOf(let.Name)
+		require.Truef(t, ok, "test case %d failed", idx)
+
+		assert.Equalf(t, tt.expected, inferred.String(), "test case %d failed", idx)
+	}
+}
+
+func TestLetPolymorphism(t *testing.T) {
+	program := parse(t, `let id = fn(x) { x }; id(1); id("a"); id(id)(true);`)
+
+	result, errors := Infer(program)
+	require.Empty(t, errors)
+
+	call := program.Statements[2].(*ast.ExpressionStatement).Expression
+	inferred, ok := result.TypeOf(call)
+	require.True(t, ok)
+	assert.Equal(t, "string", inferred.String())
+}
+
+func TestUnificationErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, "a"];`, "line 1: can not unify int with string"},
+		{`let double = fn(x) { x * 2 };` + "\n" + `double("a");`, "line 2: can not unify int -> int with string -> a"},
+		{`let f = fn(x: string) { x }; f(1);`, "line 1: can not unify string -> string with int -> a"},
+		{`if (true) { 1 } else { "a" };`, "line 1: can not unify int with string"},
+		{`let f = fn(x) { x(x) };`, "line 1: can not unify a with a -> b"},
+		{`true + false;`, "line 1: can not + bool"},
+		{`len(5);`, "line 1: can not unify [a] -> int with int -> b"},
+	}
+
+	for idx, tt := range tests {
+		_, errors := Infer(parse(t, tt.input))
+		if assert.Lenf(t, errors, 1, "test case %d failed", idx) {
+			assert.Equalf(t, tt.expected, errors[0].Error(), "test case %d failed", idx)
+		}
+	}
+}
+
+func TestVariadicPredefs(t *testing.T) {
+	program := parse(t, `printLine("a", 1, [true]); print(); printf("%d %s", 1, "a"); let s = format("%v", 2);`)
+
+	result, errors := Infer(program)
+	require.Empty(t, errors)
+
+	let := program.Statements[3].(*ast.LetStatement)
+	inferred, ok := result.TypeOf(let.Name)
+	require.True(t, ok)
+	assert.Equal(t, "string", inferred.String())
+
+	_, errors = Infer(parse(t, `format(1, 2);`))
+	if assert.Len(t, errors, 1) {
+		assert.Equal(t, "line 1: can not unify int with string", errors[0].Error())
+	}
+
+	_, errors = Infer(parse(t, `let printLine = fn(x) { x * 2 }; printLine("a");`))
+	assert.Len(t, errors, 1)
+}
+
+func TestInferExamples(t *testing.T) {
+	contents, err := ioutil.ReadFile("../fizzBuzz.hummus")
+	require.NoError(t, err)
+
+	_, errors := Infer(parse(t, string(contents)))
+	assert.Empty(t, errors)
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+
+	return program
+}
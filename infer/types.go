@@ -0,0 +1,110 @@
+package infer
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Type interface {
+	String() string
+}
+
+// Variable is a type that has not been worked out yet. Once unified with
+// another type, Instance points at it
+type Variable struct {
+	id       int
+	level    int
+	Instance Type
+}
+
+func (v *Variable) String() string { return typeString(v, map[*Variable]string{}) }
+
+// Constructor is a concrete type such as int, or a type built out of other
+// types such as [a] (Name "[]") and functions (Name "fn", where the last
+// argument is the return type)
+type Constructor struct {
+	Name string
+	Args []Type
+}
+
+func (c *Constructor) String() string { return typeString(c, map[*Variable]string{}) }
+
+var (
+	Int    = &Constructor{Name: "int"}
+	String = &Constructor{Name: "string"}
+	Bool   = &Constructor{Name: "bool"}
+	Null   = &Constructor{Name: "null"}
+)
+
+func arrayOf(element Type) *Constructor {
+	return &Constructor{Name: "[]", Args: []Type{element}}
+}
+
+func function(params []Type, ret Type) *Constructor {
+	args := append(append([]Type{}, params...), ret)
+	return &Constructor{Name: "fn", Args: args}
+}
+
+// Scheme is a type that may be used at several different types, e.g. the
+// identity function `a -> a`. Each use gets fresh copies of Quantified
+type Scheme struct {
+	Quantified []*Variable
+	Type       Type
+}
+
+func (s *Scheme) String() string { return s.Type.String() }
+
+// prune follows bound variables until it reaches a constructor or an unbound
+// variable
+func prune(t Type) Type {
+	if v, ok := t.(*Variable); ok && v.Instance != nil {
+		v.Instance = prune(v.Instance)
+		return v.Instance
+	}
+	return t
+}
+
+func typeString(t Type, names map[*Variable]string) string {
+	switch t := prune(t).(type) {
+	case *Variable:
+		if _, ok := names[t]; !ok {
+			names[t] = variableName(len(names))
+		}
+		return names[t]
+
+	case *Constructor:
+		switch t.Name {
+		case "[]":
+			return "[" + typeString(t.Args[0], names) + "]"
+
+		case "fn":
+			params := t.Args[:len(t.Args)-1]
+			ret := typeString(t.Args[len(t.Args)-1], names)
+
+			if len(params) == 1 {
+				if param, ok := prune(params[0]).(*Constructor); !ok || param.Name != "fn" {
+					return typeString(params[0], names) + " -> " + ret
+				}
+			}
+
+			stringed := []string{}
+			for _, p := range params {
+				stringed = append(stringed, typeString(p, names))
+			}
+			return "(" + strings.Join(stringed, ", ") + ") -> " + ret
+
+		default:
+			return t.Name
+		}
+	}
+
+	return "?"
+}
+
+func variableName(n int) string {
+	name := string(rune('a' + n%26))
+	if n >= 26 {
+		name += fmt.Sprintf("%d", n/26)
+	}
+	return name
+}
@@ -9,6 +9,8 @@ type Lexer struct {
 	ch           byte // current char
 
 	line int
+
+	previous token.TokenType // type of the last token read, to tell regexes from division
 }
 
 func (l *Lexer) readChar() {
@@ -37,9 +39,11 @@ func newToken(tokenType token.TokenType, ch byte, line int) token.Token {
 	}
 }
 
+// readIdentifier reads a name made of letters, underscores and, after the
+// first character, digits
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) {
+	for isLetter(l.ch) || isDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
@@ -49,18 +53,88 @@ func isLetter(ch byte) bool {
 	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads a decimal integer, or a hex, octal or binary one with a
+// 0x, 0o or 0b prefix, or a decimal float such as 1.5. Digits may be separated
+// by underscores, which are left for the parser to validate
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
-	for isDigit(l.ch) {
+	if l.ch == '0' && isRadixPrefix(l.peekChar()) {
+		l.readChar()
 		l.readChar()
+		for isDigit(l.ch) || isLetter(l.ch) {
+			l.readChar()
+		}
+		return l.input[position:l.position], token.Int
+	}
+
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+
+	if l.ch != '.' || !isDigit(l.peekChar()) {
+		return l.input[position:l.position], token.Int
+	}
+
+	l.readChar()
+	for isDigit(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+	return l.input[position:l.position], token.Float
+}
+
+func isRadixPrefix(ch byte) bool {
+	switch ch {
+	case 'x', 'X', 'o', 'O', 'b', 'B':
+		return true
+	default:
+		return false
 	}
-	return l.input[position:l.position]
 }
 
 func isDigit(ch byte) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+// endsOperand reports whether a token of type t can be the end of an operand,
+// in which case a following `/` is division rather than the start of a regex
+func endsOperand(t token.TokenType) bool {
+	switch t {
+	case token.Ident, token.Int, token.Float, token.String, token.Regex,
+		token.True, token.False, token.Null,
+		token.RightParen, token.RightBracket, token.RightBrace:
+		return true
+	default:
+		return false
+	}
+}
+
+// readRegex reads a regex literal such as /a+b/i, leaving the lexer on its
+// last character. A `/` inside the pattern is written `\/`. If there is no
+// closing `/` on the same line, it is not a regex: the lexer is left where it
+// was and readRegex reports false
+func (l *Lexer) readRegex() (string, bool) {
+	position := l.position
+	for {
+		if next := l.peekChar(); next == '\n' || next == 0 {
+			l.position, l.readPosition, l.ch = position, position+1, '/'
+			return "", false
+		}
+		l.readChar()
+
+		if l.ch == '\\' && l.peekChar() != '\n' && l.peekChar() != 0 {
+			l.readChar()
+		} else if l.ch == '/' {
+			break
+		}
+	}
+
+	for isLetter(l.peekChar()) {
+		l.readChar()
+	}
+
+	return l.input[position:l.readPosition], true
+}
+
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
 		if l.ch == '\n' {
@@ -85,6 +159,12 @@ func (l *Lexer) readString() string {
 }
 
 func (l *Lexer) NextToken() token.Token {
+	tok := l.nextToken()
+	l.previous = tok.Type
+	return tok
+}
+
+func (l *Lexer) nextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
@@ -96,13 +176,25 @@ func (l *Lexer) NextToken() token.Token {
 			l.readChar()
 			literal := string(ch) + string(l.ch)
 			tok = token.Token{Type: token.Eq, Literal: literal, Line: l.line}
+		} else if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.Arrow, Literal: literal, Line: l.line}
 		} else {
 			tok = newToken(token.Assign, l.ch, l.line)
 		}
 	case '+':
 		tok = newToken(token.Plus, l.ch, l.line)
 	case '-':
-		tok = newToken(token.Minus, l.ch, l.line)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ThinArrow, Literal: literal, Line: l.line}
+		} else {
+			tok = newToken(token.Minus, l.ch, l.line)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -117,14 +209,69 @@ func (l *Lexer) NextToken() token.Token {
 		tok = token.Token{Type: token.String, Literal: literal, Line: l.line}
 	case '/':
 		tok = newToken(token.Slash, l.ch, l.line)
+		if !endsOperand(l.previous) {
+			if literal, ok := l.readRegex(); ok {
+				tok = token.Token{Type: token.Regex, Literal: literal, Line: l.line}
+			}
+		}
 	case '*':
 		tok = newToken(token.Asterisk, l.ch, l.line)
 	case '<':
-		tok = newToken(token.Lt, l.ch, l.line)
+		if l.peekChar() == '<' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ShiftLeft, Literal: literal, Line: l.line}
+		} else {
+			tok = newToken(token.Lt, l.ch, l.line)
+		}
 	case '>':
-		tok = newToken(token.Gt, l.ch, l.line)
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.ShiftRight, Literal: literal, Line: l.line}
+		} else {
+			tok = newToken(token.Gt, l.ch, l.line)
+		}
+	case '#':
+		if l.peekChar() == '{' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.SetBrace, Literal: literal, Line: l.line}
+		} else {
+			tok = newToken(token.Illegal, l.ch, l.line)
+		}
+	case '&':
+		tok = newToken(token.Ampersand, l.ch, l.line)
+	case '^':
+		tok = newToken(token.Caret, l.ch, l.line)
+	case '~':
+		tok = newToken(token.Tilde, l.ch, l.line)
 	case ';':
 		tok = newToken(token.Semicolon, l.ch, l.line)
+	case ':':
+		tok = newToken(token.Colon, l.ch, l.line)
+	case '.':
+		tok = newToken(token.Dot, l.ch, l.line)
+	case '?':
+		switch l.peekChar() {
+		case '?':
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.Coalesce, Literal: string(ch) + string(l.ch), Line: l.line}
+		case '.':
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.QuestionDot, Literal: string(ch) + string(l.ch), Line: l.line}
+		case '[':
+			ch := l.ch
+			l.readChar()
+			tok = token.Token{Type: token.QuestionBracket, Literal: string(ch) + string(l.ch), Line: l.line}
+		default:
+			tok = newToken(token.Illegal, l.ch, l.line)
+		}
 	case '(':
 		tok = newToken(token.LeftParen, l.ch, l.line)
 	case ')':
@@ -141,6 +288,15 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RightBracket, l.ch, l.line)
 	case '%':
 		tok = newToken(token.Percent, l.ch, l.line)
+	case '|':
+		if l.peekChar() == '>' {
+			ch := l.ch
+			l.readChar()
+			literal := string(ch) + string(l.ch)
+			tok = token.Token{Type: token.Pipe, Literal: literal, Line: l.line}
+		} else {
+			tok = newToken(token.VerticalBar, l.ch, l.line)
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.Eof
@@ -152,8 +308,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Line = l.line
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Type = token.Int
-			tok.Literal = l.readNumber()
+			tok.Literal, tok.Type = l.readNumber()
 			tok.Line = l.line
 			return tok
 		} else {
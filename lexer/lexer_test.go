@@ -111,15 +111,18 @@ let result = add(five, ten);`
 }
 
 func TestAllKeywords(t *testing.T) {
-	input := `if let return else true false`
+	input := `if let const return else true false for in`
 
 	tests := []TestCase{
 		{token.If, "if"},
 		{token.Let, "let"},
+		{token.Const, "const"},
 		{token.Return, "return"},
 		{token.Else, "else"},
 		{token.True, "true"},
 		{token.False, "false"},
+		{token.For, "for"},
+		{token.In, "in"},
 	}
 
 	l := New(input)
@@ -154,6 +157,267 @@ func TestMultiCharOperators(t *testing.T) {
 	}
 }
 
+func TestPipeOperator(t *testing.T) {
+	input := `x |> double`
+	tests := []TestCase{
+		{token.Ident, "x"},
+		{token.Pipe, "|>"},
+		{token.Ident, "double"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestArrowFunction(t *testing.T) {
+	input := `(x, y) => x == y`
+	tests := []TestCase{
+		{token.LeftParen, "("},
+		{token.Ident, "x"},
+		{token.Comma, ","},
+		{token.Ident, "y"},
+		{token.RightParen, ")"},
+		{token.Arrow, "=>"},
+		{token.Ident, "x"},
+		{token.Eq, "=="},
+		{token.Ident, "y"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestTypeAnnotation(t *testing.T) {
+	input := `fn(a: int) -> bool`
+	tests := []TestCase{
+		{token.Function, "fn"},
+		{token.LeftParen, "("},
+		{token.Ident, "a"},
+		{token.Colon, ":"},
+		{token.Ident, "int"},
+		{token.RightParen, ")"},
+		{token.ThinArrow, "->"},
+		{token.Ident, "bool"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestNullSafetyOperators(t *testing.T) {
+	input := `a ?? null; a?.b.c; a?[1]; a ? b`
+	tests := []TestCase{
+		{token.Ident, "a"},
+		{token.Coalesce, "??"},
+		{token.Null, "null"},
+		{token.Semicolon, ";"},
+		{token.Ident, "a"},
+		{token.QuestionDot, "?."},
+		{token.Ident, "b"},
+		{token.Dot, "."},
+		{token.Ident, "c"},
+		{token.Semicolon, ";"},
+		{token.Ident, "a"},
+		{token.QuestionBracket, "?["},
+		{token.Int, "1"},
+		{token.RightBracket, "]"},
+		{token.Semicolon, ";"},
+		{token.Ident, "a"},
+		{token.Illegal, "?"},
+		{token.Ident, "b"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestIntegerLiterals(t *testing.T) {
+	input := `1_000 0xFF 0o17 0b1010 0x_ff 42`
+	tests := []TestCase{
+		{token.Int, "1_000"},
+		{token.Int, "0xFF"},
+		{token.Int, "0o17"},
+		{token.Int, "0b1010"},
+		{token.Int, "0x_ff"},
+		{token.Int, "42"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestFloatLiterals(t *testing.T) {
+	input := `1.5 0.25 1_000.000_1 3.x 7.`
+	tests := []TestCase{
+		{token.Float, "1.5"},
+		{token.Float, "0.25"},
+		{token.Float, "1_000.000_1"},
+		{token.Int, "3"},
+		{token.Dot, "."},
+		{token.Ident, "x"},
+		{token.Int, "7"},
+		{token.Dot, "."},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestRegexLiterals(t *testing.T) {
+	input := `/a+b/i x / 2 / y (1) /2/ [/a\/b/, /c/] /oops
+`
+	tests := []TestCase{
+		{token.Regex, "/a+b/i"},
+		{token.Ident, "x"},
+		{token.Slash, "/"},
+		{token.Int, "2"},
+		{token.Slash, "/"},
+		{token.Ident, "y"},
+		{token.LeftParen, "("},
+		{token.Int, "1"},
+		{token.RightParen, ")"},
+		{token.Slash, "/"},
+		{token.Int, "2"},
+		{token.Slash, "/"},
+		{token.LeftBracket, "["},
+		{token.Regex, "/a\\/b/"},
+		{token.Comma, ","},
+		{token.Regex, "/c/"},
+		{token.RightBracket, "]"},
+		{token.Slash, "/"},
+		{token.Ident, "oops"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestIdentifierDigits(t *testing.T) {
+	input := `atan2 x_1 2x`
+	tests := []TestCase{
+		{token.Ident, "atan2"},
+		{token.Ident, "x_1"},
+		{token.Int, "2"},
+		{token.Ident, "x"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	input := `a & b | c ^ ~d << 1 >> 2 < 3 > 4 |> f`
+	tests := []TestCase{
+		{token.Ident, "a"},
+		{token.Ampersand, "&"},
+		{token.Ident, "b"},
+		{token.VerticalBar, "|"},
+		{token.Ident, "c"},
+		{token.Caret, "^"},
+		{token.Tilde, "~"},
+		{token.Ident, "d"},
+		{token.ShiftLeft, "<<"},
+		{token.Int, "1"},
+		{token.ShiftRight, ">>"},
+		{token.Int, "2"},
+		{token.Lt, "<"},
+		{token.Int, "3"},
+		{token.Gt, ">"},
+		{token.Int, "4"},
+		{token.Pipe, "|>"},
+		{token.Ident, "f"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
+func TestSetLiteral(t *testing.T) {
+	input := `#{1, 2} # 3`
+	tests := []TestCase{
+		{token.SetBrace, "#{"},
+		{token.Int, "1"},
+		{token.Comma, ","},
+		{token.Int, "2"},
+		{token.RightBrace, "}"},
+		{token.Illegal, "#"},
+		{token.Int, "3"},
+		{token.Eof, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		assert.Equalf(t, tt.expectedType, tok.Type, "test %d failed (incorrect type)", i)
+		assert.Equalf(t, tt.expectedLiteral, tok.Literal, "test %d failed (incorrect literal)", i)
+	}
+}
+
 func TestEverything(t *testing.T) {
 	input := `let five = 5;
 let ten = 10;
@@ -2,42 +2,160 @@ package main
 
 import (
 	"fmt"
+	"hummus-lang/ast"
+	"hummus-lang/checker"
 	"hummus-lang/evaluator"
+	"hummus-lang/infer"
 	"hummus-lang/lexer"
 	"hummus-lang/object"
 	"hummus-lang/parser"
 	"hummus-lang/repl"
 	"io/ioutil"
 	"os"
+	"time"
 )
 
+// exit codes, so callers can tell why a script failed
+const (
+	exitError      = 1 // the script stopped with an uncaught error, or did not type check
+	exitParseError = 2 // the script could not be read or parsed
+	exitUsage      = 3 // the interpreter was given options it does not understand
+)
+
+// options are what the command line asks the interpreter to do. Options for
+// the interpreter come before the script; everything after the script is
+// passed on to it
+type options struct {
+	check    bool
+	infer    bool
+	script   string
+	args     []string
+	roots    []string
+	commands []string
+}
+
 func main() {
-	if len(os.Args) > 1 {
-		filename := os.Args[1]
-		contents, err := ioutil.ReadFile(filename)
-		if err != nil {
-			panic(err)
+	opts, err := parseOptions(os.Args[1:])
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitUsage)
+	}
+
+	if opts.script == "" {
+		fmt.Printf("Hello and welcome to the Hummus REPL.")
+		fmt.Printf("\n")
+		repl.Start(os.Stdin, os.Stdout, newEnvironment(opts))
+		return
+	}
+
+	program := parseFile(opts.script)
+
+	if opts.check {
+		errors := checker.Check(program)
+		for _, e := range errors {
+			fmt.Println(e)
+		}
+		if len(errors) > 0 {
+			os.Exit(exitError)
+		}
+		return
+	}
+
+	if opts.infer {
+		_, errors := infer.Infer(program)
+		for _, e := range errors {
+			fmt.Println(e)
 		}
+		if len(errors) > 0 {
+			os.Exit(exitError)
+		}
+	}
+
+	run(program, opts)
+}
 
-		l := lexer.New(string(contents))
-		p := parser.New(l)
-		program := p.ParseProgram()
-		if len(p.Errors()) > 0 {
-			for _, e := range p.Errors() {
-				fmt.Println(e)
+// parseOptions reads `check FILE`, `--infer FILE ARGS...` or `FILE ARGS...`,
+// any of them preceded by `--allow-dir DIR` and `--allow-exec COMMAND`
+// options. Scripts may only touch files under those directories, and none at
+// all if none are given, and may only run those commands
+func parseOptions(args []string) (*options, error) {
+	opts := &options{args: []string{}}
+
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--allow-dir":
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("--allow-dir needs a directory")
+			}
+			opts.roots = append(opts.roots, args[i+1])
+			i++
+		case args[i] == "--allow-exec":
+			if i+1 == len(args) {
+				return nil, fmt.Errorf("--allow-exec needs a command")
 			}
-			panic("invalid code")
+			opts.commands = append(opts.commands, args[i+1])
+			i++
+		case args[i] == "--infer":
+			opts.infer = true
+		case args[i] == "check" && !opts.check && !opts.infer:
+			opts.check = true
+		default:
+			opts.script = args[i]
+			opts.args = append(opts.args, args[i+1:]...)
+			i = len(args)
 		}
+	}
+
+	if (opts.check || opts.infer) && opts.script == "" {
+		return nil, fmt.Errorf("no script given to check")
+	}
 
-		res := evaluator.Eval(program, object.NewEnvironment())
-		if res != nil && res.Type() == object.ErrorObj {
-			fmt.Printf("%s\n", res.Printable())
-			os.Exit(1)
+	return opts, nil
+}
+
+func newEnvironment(opts *options) *object.Environment {
+	env := object.NewEnvironment()
+	if err := evaluator.InstallFileAccess(env, opts.roots...); err != nil {
+		fmt.Println(err)
+		os.Exit(exitUsage)
+	}
+	evaluator.InstallRandom(env, time.Now().UnixNano())
+	evaluator.InstallProcess(env, opts.args, os.Exit)
+	if err := evaluator.InstallExec(env, opts.commands...); err != nil {
+		fmt.Println(err)
+		os.Exit(exitUsage)
+	}
+	// the REPL reads its own input from stdin, so only scripts are given it
+	if opts.script != "" {
+		evaluator.InstallInput(env, os.Stdin)
+	}
+	return env
+}
+
+func run(program *ast.Program, opts *options) {
+	res := evaluator.Eval(program, newEnvironment(opts))
+	if res != nil && res.Type() == object.ErrorObj {
+		fmt.Printf("%s\n", res.Printable())
+		os.Exit(exitError)
+	}
+}
+
+func parseFile(filename string) *ast.Program {
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(exitParseError)
+	}
+
+	l := lexer.New(string(contents))
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		for _, e := range p.Errors() {
+			fmt.Println(e)
 		}
-	} else {
-		fmt.Printf("Hello and welcome to the Hummus REPL.")
-		fmt.Printf("\n")
-		repl.Start(os.Stdin, os.Stdout)
+		os.Exit(exitParseError)
 	}
 
+	return program
 }
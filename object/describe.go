@@ -0,0 +1,65 @@
+package object
+
+import "strings"
+
+// describe lays out obj the way Inspect does, or Printable does if printable
+// is set. Arrays and hashes can be changed to hold themselves, so one met
+// again inside itself is shown as [...] or {...} rather than followed forever
+func describe(obj Object, printable bool) string {
+	d := &describer{printable: printable, visiting: map[Object]bool{}}
+	return d.describe(obj)
+}
+
+type describer struct {
+	printable bool
+	visiting  map[Object]bool // the arrays and hashes being laid out
+}
+
+func (d *describer) describe(obj Object) string {
+	switch x := obj.(type) {
+	case *Array:
+		if d.visiting[x] {
+			return "[...]"
+		}
+		d.visiting[x] = true
+		defer delete(d.visiting, x)
+
+		return "[" + strings.Join(d.all(x.Elements), ", ") + "]"
+
+	case *Hash:
+		if d.visiting[x] {
+			return "{...}"
+		}
+		d.visiting[x] = true
+		defer delete(d.visiting, x)
+
+		pairs := []string{}
+		for _, pair := range x.Items() {
+			pairs = append(pairs, d.describe(pair.Key)+": "+d.describe(pair.Value))
+		}
+		return "{" + strings.Join(pairs, ", ") + "}"
+
+	case *Tuple:
+		return tupleString(d.all(x.Elements))
+
+	case *Set:
+		return "#{" + strings.Join(d.all(x.Items()), ", ") + "}"
+
+	case *Variant:
+		return x.describe(d.all(x.Values))
+
+	default:
+		if d.printable {
+			return obj.Printable()
+		}
+		return obj.Inspect()
+	}
+}
+
+func (d *describer) all(objs []Object) []string {
+	described := make([]string, len(objs))
+	for i, obj := range objs {
+		described[i] = d.describe(obj)
+	}
+	return described
+}
@@ -1,13 +1,17 @@
 package object
 
+import "fmt"
+
 type Environment struct {
-	store map[string]Object
-	outer *Environment
+	store     map[string]Object
+	constants map[string]bool
+	outer     *Environment
 }
 
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
-	return &Environment{store: s, outer: nil}
+	c := make(map[string]bool)
+	return &Environment{store: s, constants: c, outer: nil}
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
@@ -24,7 +28,21 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Set binds name to val in this environment. Names bound with SetConstant can
+// not be rebound, an *Error is returned instead
 func (e *Environment) Set(name string, val Object) Object {
+	if e.constants[name] {
+		return &Error{Message: fmt.Sprintf("can not reassign constant `%s`", name)}
+	}
+
 	e.store[name] = val
 	return val
 }
+
+func (e *Environment) SetConstant(name string, val Object) Object {
+	result := e.Set(name, val)
+	if result.Type() != ErrorObj {
+		e.constants[name] = true
+	}
+	return result
+}
@@ -0,0 +1,160 @@
+package object
+
+// Equal reports whether a and b hold the same value. Integers are equal to
+// floats with the same whole number value, as they are with ==. Times are equal when
+// they are the same instant, whatever their time zones. Arrays, tuples, sets,
+// hashes and enum variants are compared element by element; functions, enums
+// and other values without contents of their own are only equal to themselves
+func Equal(a Object, b Object) bool {
+	return EqualFunc(a, b, nil)
+}
+
+// EqualFunc is Equal, but first asks compare about every pair of values it
+// meets, including a and b themselves. When compare returns handled as false
+// the values are compared structurally instead
+func EqualFunc(a Object, b Object, compare func(a Object, b Object) (equal bool, handled bool)) bool {
+	e := &equality{compare: compare, visiting: map[[2]Object]bool{}}
+	return e.equal(a, b)
+}
+
+type equality struct {
+	compare func(a Object, b Object) (bool, bool)
+
+	// pairs of containers currently being compared. Meeting one again means
+	// the values are cyclic, and they are equal as far as the cycle goes
+	visiting map[[2]Object]bool
+}
+
+func (e *equality) equal(a Object, b Object) bool {
+	if e.compare != nil {
+		if equal, handled := e.compare(a, b); handled {
+			return equal
+		}
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value
+		case *Float:
+			n, ok := WholeNumber(b.Value)
+			return ok && a.Value == n
+		}
+		return false
+
+	case *Float:
+		switch b := b.(type) {
+		case *Float:
+			return a.Value == b.Value
+		case *Integer:
+			n, ok := WholeNumber(a.Value)
+			return ok && n == b.Value
+		}
+		return false
+
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+
+	case *Null:
+		_, ok := b.(*Null)
+		return ok
+
+	case *Time:
+		b, ok := b.(*Time)
+		return ok && a.Value.Equal(b.Value)
+
+	case *Duration:
+		b, ok := b.(*Duration)
+		return ok && a.Value == b.Value
+
+	case *Array:
+		b, ok := b.(*Array)
+		return ok && e.elements(a, b, a.Elements, b.Elements)
+
+	case *Tuple:
+		b, ok := b.(*Tuple)
+		return ok && e.elements(a, b, a.Elements, b.Elements)
+
+	case *Variant:
+		b, ok := b.(*Variant)
+		return ok && a.Variant == b.Variant && e.elements(a, b, a.Values, b.Values)
+
+	case *Set:
+		b, ok := b.(*Set)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+
+		for key, x := range a.Elements {
+			y, ok := b.Elements[key]
+			if !ok || !e.equal(x, y) {
+				return false
+			}
+		}
+		return true
+
+	case *Hash:
+		b, ok := b.(*Hash)
+		return ok && e.pairs(a, b)
+
+	default:
+		return a == b
+	}
+}
+
+func (e *equality) pairs(a *Hash, b *Hash) bool {
+	if a == b {
+		return true
+	}
+
+	pair := [2]Object{a, b}
+	if e.visiting[pair] {
+		return true
+	}
+	e.visiting[pair] = true
+	defer delete(e.visiting, pair)
+
+	if len(a.Pairs) != len(b.Pairs) {
+		return false
+	}
+
+	for key, x := range a.Pairs {
+		y, ok := b.Pairs[key]
+		if !ok || !e.equal(x.Value, y.Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *equality) elements(a Object, b Object, x []Object, y []Object) bool {
+	if a == b {
+		return true
+	}
+
+	pair := [2]Object{a, b}
+	if e.visiting[pair] {
+		return true
+	}
+	e.visiting[pair] = true
+	defer delete(e.visiting, pair)
+
+	if len(x) != len(y) {
+		return false
+	}
+
+	for i := range x {
+		if !e.equal(x[i], y[i]) {
+			return false
+		}
+	}
+
+	return true
+}
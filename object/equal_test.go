@@ -0,0 +1,61 @@
+package object
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEqual(t *testing.T) {
+	assert.True(t, Equal(&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&Integer{Value: 1}}}))
+	assert.False(t, Equal(&Array{Elements: []Object{&Integer{Value: 1}}}, &Array{Elements: []Object{&String{Value: "1"}}}))
+	assert.False(t, Equal(&Integer{Value: 1}, &Array{Elements: []Object{&Integer{Value: 1}}}))
+}
+
+func TestEqualNumbers(t *testing.T) {
+	assert.True(t, Equal(&Integer{Value: 1}, &Float{Value: 1}))
+	assert.True(t, Equal(&Float{Value: -3}, &Integer{Value: -3}))
+	assert.False(t, Equal(&Integer{Value: 1}, &Float{Value: 1.5}))
+	assert.True(t, Equal(&Tuple{Elements: []Object{&Array{Elements: []Object{&Integer{Value: 2}}}}}, &Tuple{Elements: []Object{&Array{Elements: []Object{&Float{Value: 2}}}}}))
+
+	one, _ := HashKeyOf(&Integer{Value: 1})
+	oneFloat, _ := HashKeyOf(&Float{Value: 1})
+	half, _ := HashKeyOf(&Float{Value: 0.5})
+	assert.Equal(t, one, oneFloat)
+	assert.NotEqual(t, one, half)
+
+	set := NewSet()
+	set.Add(&Integer{Value: 1})
+	set.Add(&Float{Value: 1})
+	assert.Len(t, set.Elements, 1)
+}
+
+func TestEqualCycles(t *testing.T) {
+	a := &Array{}
+	a.Elements = []Object{&Integer{Value: 1}, a}
+
+	b := &Array{}
+	b.Elements = []Object{&Integer{Value: 1}, b}
+
+	c := &Array{}
+	c.Elements = []Object{&Integer{Value: 2}, c}
+
+	assert.True(t, Equal(a, b))
+	assert.False(t, Equal(a, c))
+}
+
+func TestEqualFunc(t *testing.T) {
+	sameLength := func(a Object, b Object) (bool, bool) {
+		x, ok := a.(*String)
+		y, ok2 := b.(*String)
+		if !ok || !ok2 {
+			return false, false
+		}
+		return len(x.Value) == len(y.Value), true
+	}
+
+	a := &Tuple{Elements: []Object{&String{Value: "ab"}}}
+	b := &Tuple{Elements: []Object{&String{Value: "cd"}}}
+
+	assert.True(t, EqualFunc(a, b, sameLength))
+	assert.False(t, Equal(a, b))
+}
@@ -0,0 +1,88 @@
+package object
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+// HashKey identifies a value by its contents, so that equal values have equal
+// keys no matter where they came from
+type HashKey struct {
+	Type  ObjectType
+	Value uint64
+}
+
+// WholeNumber returns f as an integer, if it has no fractional part and fits
+// in one
+func WholeNumber(f float64) (int64, bool) {
+	if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// HashKeyOf returns the hash key of obj. Only immutable values can be hashed:
+// integers, floats, strings, booleans, null, and tuples and enum variants made
+// of them
+func HashKeyOf(obj Object) (HashKey, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return HashKey{Type: obj.Type(), Value: uint64(obj.Value)}, true
+
+	case *Float:
+		// whole floats are hashed as the integers they are equal to
+		if n, ok := WholeNumber(obj.Value); ok {
+			return HashKey{Type: IntegerObj, Value: uint64(n)}, true
+		}
+		return HashKey{Type: obj.Type(), Value: math.Float64bits(obj.Value)}, true
+
+	case *String:
+		h := fnv.New64a()
+		h.Write([]byte(obj.Value))
+		return HashKey{Type: obj.Type(), Value: h.Sum64()}, true
+
+	case *Boolean:
+		if obj.Value {
+			return HashKey{Type: obj.Type(), Value: 1}, true
+		}
+		return HashKey{Type: obj.Type(), Value: 0}, true
+
+	case *Null:
+		return HashKey{Type: obj.Type(), Value: 0}, true
+
+	case *Time:
+		return HashKey{Type: obj.Type(), Value: uint64(obj.Value.UnixNano())}, true
+
+	case *Duration:
+		return HashKey{Type: obj.Type(), Value: uint64(obj.Value)}, true
+
+	case *Tuple:
+		return combineHashKeys(obj.Type(), "", obj.Elements)
+
+	case *Variant:
+		return combineHashKeys(obj.Type(), obj.Variant.Enum.Name+"."+obj.Variant.Name, obj.Values)
+
+	default:
+		return HashKey{}, false
+	}
+}
+
+func combineHashKeys(t ObjectType, name string, elements []Object) (HashKey, bool) {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+
+	buf := make([]byte, 8)
+	for _, e := range elements {
+		key, ok := HashKeyOf(e)
+		if !ok {
+			return HashKey{}, false
+		}
+
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf, key.Value)
+		h.Write(buf)
+	}
+
+	return HashKey{Type: t, Value: h.Sum64()}, true
+}
@@ -1,10 +1,16 @@
 package object
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"hummus-lang/ast"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type ObjectType string
@@ -20,6 +26,18 @@ const (
 	FunctionObj        = "FUNCTION"
 	PredefinedFunction = "PREDEFINED_FUNCTION"
 	ArrayObj           = "ARRAY"
+	EnumObj            = "ENUM"
+	VariantTypeObj     = "VARIANT_TYPE"
+	VariantObj         = "VARIANT"
+	TupleObj           = "TUPLE"
+	SetObj             = "SET"
+	FloatObj           = "FLOAT"
+	ModuleObj          = "MODULE"
+	FileObj            = "FILE"
+	HashObj            = "HASH"
+	RegexObj           = "REGEX"
+	TimeObj            = "TIME"
+	DurationObj        = "DURATION"
 )
 
 type Object interface {
@@ -36,6 +54,22 @@ func (i *Integer) Inspect() string   { return fmt.Sprintf("%d", i.Value) }
 func (i *Integer) Type() ObjectType  { return IntegerObj }
 func (i *Integer) Printable() string { return fmt.Sprintf("%d", i.Value) }
 
+type Float struct {
+	Value float64
+}
+
+// Inspect always shows a decimal point or exponent, so 2.0 does not look like
+// the integer 2
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if strings.ContainsAny(s, ".eIN") {
+		return s
+	}
+	return s + ".0"
+}
+func (f *Float) Type() ObjectType  { return FloatObj }
+func (f *Float) Printable() string { return f.Inspect() }
+
 type String struct {
 	Value string
 }
@@ -116,34 +150,303 @@ func (f *Predef) Printable() string { return "predefined function" }
 
 type Array struct {
 	Elements []Object
+	Frozen   bool
 }
 
-func (a *Array) Inspect() string {
-	stringedExpressions := make([]string, 0)
+func (a *Array) Inspect() string   { return describe(a, false) }
+func (a *Array) Type() ObjectType  { return ArrayObj }
+func (a *Array) Printable() string { return describe(a, true) }
+
+type Enum struct {
+	Name     string
+	Variants []*VariantType
 
-	for _, curr := range a.Elements {
-		stringedExpressions = append(stringedExpressions, curr.Inspect())
+	// Operators maps an operator, or "[]" for indexing, to the function that
+	// implements it for this enum's variants
+	Operators map[string]Object
+}
+
+func (e *Enum) Inspect() string {
+	variants := []string{}
+	for _, v := range e.Variants {
+		variants = append(variants, v.signature())
 	}
 
-	var out bytes.Buffer
-	out.WriteString("[")
-	out.WriteString(strings.Join(stringedExpressions, ", "))
-	out.WriteString("]")
+	return fmt.Sprintf("enum %s { %s }", e.Name, strings.Join(variants, ", "))
+}
+func (e *Enum) Type() ObjectType  { return EnumObj }
+func (e *Enum) Printable() string { return e.Inspect() }
 
-	return out.String()
+// Variant returns the variant of e called name, or nil if there is none
+func (e *Enum) Variant(name string) *VariantType {
+	for _, v := range e.Variants {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
 }
-func (a *Array) Type() ObjectType { return ArrayObj }
-func (a *Array) Printable() string {
-	stringedExpressions := make([]string, 0)
 
-	for _, curr := range a.Elements {
-		stringedExpressions = append(stringedExpressions, curr.Printable())
+// VariantType is one case of an enum. Calling it with a value for each of its
+// fields constructs a Variant
+type VariantType struct {
+	Enum   *Enum
+	Name   string
+	Fields []string
+}
+
+func (vt *VariantType) signature() string {
+	if len(vt.Fields) == 0 {
+		return vt.Name
 	}
+	return fmt.Sprintf("%s(%s)", vt.Name, strings.Join(vt.Fields, ", "))
+}
 
-	var out bytes.Buffer
-	out.WriteString("[")
-	out.WriteString(strings.Join(stringedExpressions, ", "))
-	out.WriteString("]")
+func (vt *VariantType) Inspect() string   { return vt.Enum.Name + "." + vt.signature() }
+func (vt *VariantType) Type() ObjectType  { return VariantTypeObj }
+func (vt *VariantType) Printable() string { return vt.Inspect() }
 
-	return out.String()
-}
\ No newline at end of file
+type Variant struct {
+	Variant *VariantType
+	Values  []Object
+}
+
+func (v *Variant) Inspect() string   { return describe(v, false) }
+func (v *Variant) Type() ObjectType  { return VariantObj }
+func (v *Variant) Printable() string { return describe(v, true) }
+
+func (v *Variant) describe(values []string) string {
+	name := v.Variant.Enum.Name + "." + v.Variant.Name
+	if len(values) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(values, ", "))
+}
+
+// Field returns the value of the field called name, if v has one
+func (v *Variant) Field(name string) (Object, bool) {
+	for i, f := range v.Variant.Fields {
+		if f == name {
+			return v.Values[i], true
+		}
+	}
+	return nil, false
+}
+
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Inspect() string   { return describe(t, false) }
+func (t *Tuple) Type() ObjectType  { return TupleObj }
+func (t *Tuple) Printable() string { return describe(t, true) }
+
+func tupleString(elements []string) string {
+	if len(elements) == 1 {
+		return "(" + elements[0] + ",)"
+	}
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// Set is an unordered collection of distinct hashable values. Elements are
+// kept in the order they were first added so that sets print predictably
+type Set struct {
+	Elements map[HashKey]Object
+	Frozen   bool
+	order    []HashKey
+}
+
+func NewSet() *Set {
+	return &Set{Elements: make(map[HashKey]Object)}
+}
+
+// Add puts obj in the set, returning false if obj can not be hashed
+func (s *Set) Add(obj Object) bool {
+	key, ok := HashKeyOf(obj)
+	if !ok {
+		return false
+	}
+
+	if _, exists := s.Elements[key]; !exists {
+		s.Elements[key] = obj
+		s.order = append(s.order, key)
+	}
+	return true
+}
+
+// Delete takes obj out of the set, returning false if it was not in it
+func (s *Set) Delete(obj Object) bool {
+	key, ok := HashKeyOf(obj)
+	if !ok {
+		return false
+	}
+
+	if _, exists := s.Elements[key]; !exists {
+		return false
+	}
+	delete(s.Elements, key)
+	s.order = withoutKey(s.order, key)
+	return true
+}
+
+func withoutKey(order []HashKey, key HashKey) []HashKey {
+	for i, k := range order {
+		if k == key {
+			return append(order[:i:i], order[i+1:]...)
+		}
+	}
+	return order
+}
+
+func (s *Set) Contains(obj Object) bool {
+	key, ok := HashKeyOf(obj)
+	if !ok {
+		return false
+	}
+
+	_, exists := s.Elements[key]
+	return exists
+}
+
+// Items returns the elements of the set in the order they were added
+func (s *Set) Items() []Object {
+	items := make([]Object, 0, len(s.order))
+	for _, key := range s.order {
+		items = append(items, s.Elements[key])
+	}
+	return items
+}
+
+func (s *Set) Inspect() string   { return describe(s, false) }
+func (s *Set) Type() ObjectType  { return SetObj }
+func (s *Set) Printable() string { return describe(s, true) }
+
+// Module is a namespace of predefs and constants, such as `math`, whose
+// members are reached with `.`
+type Module struct {
+	Name    string
+	Members map[string]Object
+}
+
+func (m *Module) Inspect() string {
+	names := []string{}
+	for name := range m.Members {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return fmt.Sprintf("module %s { %s }", m.Name, strings.Join(names, ", "))
+}
+func (m *Module) Type() ObjectType  { return ModuleObj }
+func (m *Module) Printable() string { return "module " + m.Name }
+
+// File is an open file. Reader is nil if it was not opened for reading, and
+// Writer if it was not opened for writing
+type File struct {
+	Name   string
+	Reader *bufio.Reader
+	Writer io.Writer
+	Closer io.Closer
+	Closed bool
+}
+
+func (f *File) Inspect() string   { return fmt.Sprintf("file(%s)", f.Name) }
+func (f *File) Type() ObjectType  { return FileObj }
+func (f *File) Printable() string { return f.Inspect() }
+
+// Regex is a compiled regular expression, from a literal or from regex.compile
+type Regex struct {
+	Pattern string
+	Flags   string
+	Regexp  *regexp.Regexp
+}
+
+func (r *Regex) Inspect() string   { return "/" + r.Pattern + "/" + r.Flags }
+func (r *Regex) Type() ObjectType  { return RegexObj }
+func (r *Regex) Printable() string { return r.Inspect() }
+
+// Time is an instant, along with the time zone it is shown in
+type Time struct {
+	Value time.Time
+}
+
+func (t *Time) Inspect() string   { return fmt.Sprintf("time(%s)", t.Printable()) }
+func (t *Time) Type() ObjectType  { return TimeObj }
+func (t *Time) Printable() string { return t.Value.Format(time.RFC3339Nano) }
+
+// Duration is the time between two instants, such as 1h30m0s
+type Duration struct {
+	Value time.Duration
+}
+
+func (d *Duration) Inspect() string   { return fmt.Sprintf("duration(%s)", d.Printable()) }
+func (d *Duration) Type() ObjectType  { return DurationObj }
+func (d *Duration) Printable() string { return d.Value.String() }
+
+type HashPair struct {
+	Key   Object
+	Value Object
+}
+
+// Hash maps keys to values, remembering the order keys were first added in
+type Hash struct {
+	Pairs  map[HashKey]HashPair
+	Frozen bool
+	order  []HashKey
+}
+
+func NewHash() *Hash {
+	return &Hash{Pairs: make(map[HashKey]HashPair)}
+}
+
+// Set binds key to value, returning false if key can not be hashed
+func (h *Hash) Set(key Object, value Object) bool {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return false
+	}
+
+	if _, exists := h.Pairs[hashKey]; !exists {
+		h.order = append(h.order, hashKey)
+	}
+	h.Pairs[hashKey] = HashPair{Key: key, Value: value}
+	return true
+}
+
+func (h *Hash) Get(key Object) (Object, bool) {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return nil, false
+	}
+
+	pair, exists := h.Pairs[hashKey]
+	return pair.Value, exists
+}
+
+// Delete removes key from the hash, returning false if it was not there
+func (h *Hash) Delete(key Object) bool {
+	hashKey, ok := HashKeyOf(key)
+	if !ok {
+		return false
+	}
+
+	if _, exists := h.Pairs[hashKey]; !exists {
+		return false
+	}
+	delete(h.Pairs, hashKey)
+	h.order = withoutKey(h.order, hashKey)
+	return true
+}
+
+// Items returns the pairs of the hash in the order their keys were added
+func (h *Hash) Items() []HashPair {
+	items := make([]HashPair, 0, len(h.order))
+	for _, key := range h.order {
+		items = append(items, h.Pairs[key])
+	}
+	return items
+}
+
+func (h *Hash) Inspect() string   { return describe(h, false) }
+func (h *Hash) Type() ObjectType  { return HashObj }
+func (h *Hash) Printable() string { return describe(h, true) }
\ No newline at end of file
@@ -6,13 +6,22 @@ import (
 	"hummus-lang/lexer"
 	"hummus-lang/token"
 	"strconv"
+	"strings"
 )
 
 const (
 	_ int = iota
 	PrecedenceLowest
+	PrecedencePipe
+	PrecedenceCoalesce
 	PrecedenceEquals
 	PrecedenceLessGreater
+	// bitwise operators bind tighter than comparisons, so `flags & 4 == 4`
+	// tests a bit as it would in Go, Python or Rust
+	PrecedenceBitOr
+	PrecedenceBitXor
+	PrecedenceBitAnd
+	PrecedenceShift
 	PrecedenceSum
 	PrecedenceProduct
 	PrecedencePrefix
@@ -21,10 +30,17 @@ const (
 )
 
 var precedences = map[token.TokenType]int{
+	token.Pipe:        PrecedencePipe,
+	token.Coalesce:    PrecedenceCoalesce,
 	token.Eq:          PrecedenceEquals,
 	token.NotEq:       PrecedenceEquals,
 	token.Lt:          PrecedenceLessGreater,
 	token.Gt:          PrecedenceLessGreater,
+	token.VerticalBar: PrecedenceBitOr,
+	token.Caret:       PrecedenceBitXor,
+	token.Ampersand:   PrecedenceBitAnd,
+	token.ShiftLeft:   PrecedenceShift,
+	token.ShiftRight:  PrecedenceShift,
 	token.Plus:        PrecedenceSum,
 	token.Minus:       PrecedenceSum,
 	token.Slash:       PrecedenceProduct,
@@ -32,6 +48,18 @@ var precedences = map[token.TokenType]int{
 	token.Percent:     PrecedenceProduct,
 	token.LeftParen:   PrecedenceCall,
 	token.LeftBracket: PrecedenceIndex,
+
+	token.QuestionBracket: PrecedenceIndex,
+	token.Dot:             PrecedenceIndex,
+	token.QuestionDot:     PrecedenceIndex,
+}
+
+var typeNames = map[string]bool{
+	"int":    true,
+	"float":  true,
+	"string": true,
+	"bool":   true,
+	"any":    true,
 }
 
 type (
@@ -81,7 +109,7 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 }
 
 func (p *Parser) parseLetStatement() *ast.LetStatement {
-	stmt := &ast.LetStatement{Token: p.curToken}
+	stmt := &ast.LetStatement{Token: p.curToken, Constant: p.curTokenIs(token.Const)}
 
 	if !p.expectPeek(token.Ident) {
 		return nil
@@ -89,6 +117,10 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 
 	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
+	if !p.parseOptionalType(stmt.Name) {
+		return nil
+	}
+
 	if !p.expectPeek(token.Assign) {
 		return nil
 	}
@@ -108,6 +140,60 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	return stmt
 }
 
+func (p *Parser) parseEnumStatement() *ast.EnumStatement {
+	stmt := &ast.EnumStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.LeftBrace) {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for !p.peekTokenIs(token.RightBrace) {
+		if len(stmt.Variants) > 0 && !p.expectPeek(token.Comma) {
+			return nil
+		}
+
+		if !p.expectPeek(token.Ident) {
+			return nil
+		}
+
+		variant := &ast.EnumVariant{
+			Name:   &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal},
+			Fields: []*ast.Identifier{},
+		}
+
+		if seen[variant.Name.Value] {
+			p.addError(fmt.Sprintf("duplicate variant %s in enum %s on line %d", variant.Name.Value, stmt.Name.Value, p.curToken.Line))
+			return nil
+		}
+		seen[variant.Name.Value] = true
+
+		if p.peekTokenIs(token.LeftParen) {
+			p.nextToken()
+			variant.Fields = p.parseFunctionParameters()
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+
+		stmt.Variants = append(stmt.Variants, variant)
+	}
+
+	p.nextToken()
+
+	if p.peekTokenIs(token.Semicolon) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	stmt := &ast.ReturnStatement{Token: p.curToken}
 
@@ -214,6 +300,15 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 
 	lit.Parameters = p.parseFunctionParameters()
 
+	if p.peekTokenIs(token.ThinArrow) {
+		p.nextToken()
+		p.nextToken()
+		lit.ReturnType = p.parseTypeAnnotation()
+		if lit.ReturnType == nil {
+			return nil
+		}
+	}
+
 	if !p.expectPeek(token.LeftBrace) {
 		return nil
 	}
@@ -223,14 +318,208 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 	return lit
 }
 
+// parseArrowFunction parses the body of `(x, y) => x + y` once the parameters
+// have been read. The body is a single expression which is implicitly returned
+func (p *Parser) parseArrowFunction(parameters []*ast.Identifier) ast.Expression {
+	lit := &ast.FunctionLiteral{
+		Token:      token.Token{Type: token.Function, Literal: "fn", Line: p.curToken.Line},
+		Parameters: parameters,
+	}
+
+	if !p.expectPeek(token.Arrow) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+	stmt.Expression = p.parseExpression(PrecedenceLowest)
+	if stmt.Expression == nil {
+		return nil
+	}
+
+	lit.Body = &ast.BlockStatement{Token: stmt.Token, Statements: []ast.Statement{stmt}}
+
+	return lit
+}
+
+// peekArrowParameters looks ahead from the current `(` without consuming any
+// tokens and reports whether it starts the parameter list of an arrow function.
+// Parameters may be annotated with types, as those of `fn` can
+func (p *Parser) peekArrowParameters() bool {
+	l := *p.l
+	tok := p.peekToken
+
+	if tok.Type != token.RightParen {
+		for {
+			if tok.Type != token.Ident {
+				return false
+			}
+
+			tok = l.NextToken()
+			if tok.Type == token.Colon {
+				tok = skipTypeAnnotation(&l)
+			}
+			if tok.Type == token.RightParen {
+				break
+			}
+			if tok.Type != token.Comma {
+				return false
+			}
+
+			tok = l.NextToken()
+		}
+	}
+
+	return l.NextToken().Type == token.Arrow
+}
+
+// skipTypeAnnotation reads past a type annotation, returning the token after
+// it. The annotation is checked properly when the parameters are parsed
+func skipTypeAnnotation(l *lexer.Lexer) token.Token {
+	depth := 0
+	for {
+		tok := l.NextToken()
+		switch tok.Type {
+		case token.LeftParen, token.LeftBracket:
+			depth++
+		case token.RightParen, token.RightBracket:
+			if depth == 0 {
+				return tok
+			}
+			depth--
+		case token.Comma:
+			if depth == 0 {
+				return tok
+			}
+		case token.Eof:
+			return tok
+		}
+	}
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	arr := &ast.ArrayLiteral{Token: p.curToken}
 
-	arr.Elements = p.parseExpressionList(token.RightBracket)
+	if p.peekTokenIs(token.RightBracket) {
+		p.nextToken()
+		arr.Elements = []ast.Expression{}
+		return arr
+	}
+
+	p.nextToken()
+	first := p.parseExpression(PrecedenceLowest)
+
+	if p.peekTokenIs(token.For) {
+		return p.parseComprehension(arr.Token, first, token.RightBracket)
+	}
+
+	arr.Elements = p.parseRemainingExpressionList(first, token.RightBracket)
 
 	return arr
 }
 
+// parseComprehension parses the `for x in xs if cond` part of a comprehension
+// once its element expression has been read
+func (p *Parser) parseComprehension(start token.Token, element ast.Expression, ending token.TokenType) ast.Expression {
+	comprehension := &ast.Comprehension{Token: start, Element: element}
+
+	if !p.expectPeek(token.For) {
+		return nil
+	}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	comprehension.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.In) {
+		return nil
+	}
+
+	p.nextToken()
+	comprehension.Iterable = p.parseExpression(PrecedenceLowest)
+
+	if p.peekTokenIs(token.If) {
+		p.nextToken()
+		p.nextToken()
+		comprehension.Condition = p.parseExpression(PrecedenceLowest)
+	}
+
+	if !p.expectPeek(ending) {
+		return nil
+	}
+
+	return comprehension
+}
+
+func (p *Parser) parseSetLiteral() ast.Expression {
+	set := &ast.SetLiteral{Token: p.curToken}
+
+	if p.peekTokenIs(token.RightBrace) {
+		p.nextToken()
+		set.Elements = []ast.Expression{}
+		return set
+	}
+
+	p.nextToken()
+	first := p.parseExpression(PrecedenceLowest)
+
+	if p.peekTokenIs(token.For) {
+		return p.parseComprehension(set.Token, first, token.RightBrace)
+	}
+
+	set.Elements = p.parseRemainingExpressionList(first, token.RightBrace)
+
+	return set
+}
+
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Keys: []ast.Expression{}, Values: []ast.Expression{}}
+
+	for !p.peekTokenIs(token.RightBrace) {
+		p.nextToken()
+		key := p.parseExpression(PrecedenceLowest)
+
+		if !p.expectPeek(token.Colon) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(PrecedenceLowest)
+
+		if len(hash.Keys) == 0 && p.peekTokenIs(token.For) {
+			return p.parseHashComprehension(hash.Token, key, value)
+		}
+
+		hash.Keys = append(hash.Keys, key)
+		hash.Values = append(hash.Values, value)
+
+		if !p.peekTokenIs(token.RightBrace) && !p.expectPeek(token.Comma) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RightBrace) {
+		return nil
+	}
+
+	return hash
+}
+
+// parseHashComprehension parses the rest of `{k: v for x in xs if cond}` once
+// its first key and value have been read
+func (p *Parser) parseHashComprehension(start token.Token, key ast.Expression, value ast.Expression) ast.Expression {
+	comprehension, ok := p.parseComprehension(start, value, token.RightBrace).(*ast.Comprehension)
+	if !ok {
+		return nil
+	}
+
+	comprehension.Key = key
+	return comprehension
+}
+
 func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	identifiers := []*ast.Identifier{}
 
@@ -242,12 +531,18 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	p.nextToken()
 
 	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.parseOptionalType(ident) {
+		return nil
+	}
 	identifiers = append(identifiers, ident)
 
 	for p.peekTokenIs(token.Comma) {
 		p.nextToken()
 		p.nextToken()
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.parseOptionalType(ident) {
+			return nil
+		}
 		identifiers = append(identifiers, ident)
 	}
 
@@ -258,10 +553,86 @@ func (p *Parser) parseFunctionParameters() []*ast.Identifier {
 	return identifiers
 }
 
+// parseOptionalType reads a `: type` annotation following ident, if there is
+// one. It returns false if the annotation was malformed
+func (p *Parser) parseOptionalType(ident *ast.Identifier) bool {
+	if !p.peekTokenIs(token.Colon) {
+		return true
+	}
+
+	p.nextToken()
+	p.nextToken()
+
+	ident.Type = p.parseTypeAnnotation()
+
+	return ident.Type != nil
+}
+
+func (p *Parser) parseTypeAnnotation() *ast.TypeAnnotation {
+	annotation := &ast.TypeAnnotation{Token: p.curToken}
+
+	switch p.curToken.Type {
+	case token.LeftBracket:
+		annotation.Name = "[]"
+
+		p.nextToken()
+		annotation.Element = p.parseTypeAnnotation()
+		if annotation.Element == nil || !p.expectPeek(token.RightBracket) {
+			return nil
+		}
+	case token.Function:
+		annotation.Name = "fn"
+		annotation.Parameters = []*ast.TypeAnnotation{}
+
+		if !p.expectPeek(token.LeftParen) {
+			return nil
+		}
+
+		for !p.peekTokenIs(token.RightParen) {
+			if len(annotation.Parameters) > 0 && !p.expectPeek(token.Comma) {
+				return nil
+			}
+
+			p.nextToken()
+			param := p.parseTypeAnnotation()
+			if param == nil {
+				return nil
+			}
+			annotation.Parameters = append(annotation.Parameters, param)
+		}
+
+		p.nextToken()
+
+		if !p.expectPeek(token.ThinArrow) {
+			return nil
+		}
+
+		p.nextToken()
+		annotation.Return = p.parseTypeAnnotation()
+		if annotation.Return == nil {
+			return nil
+		}
+	case token.Null:
+		annotation.Name = "null"
+	case token.Ident:
+		if !typeNames[p.curToken.Literal] {
+			p.addError(fmt.Sprintf("unknown type %s on line %d", p.curToken.Literal, p.curToken.Line))
+			return nil
+		}
+		annotation.Name = p.curToken.Literal
+	default:
+		p.addError(fmt.Sprintf("expected type, got %s instead on line %d", p.curToken.Type, p.curToken.Line))
+		return nil
+	}
+
+	return annotation
+}
+
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{
-		Token: p.curToken,
-		Left: left,
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.QuestionBracket),
 	}
 
 	p.nextToken()
@@ -274,6 +645,22 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token:    p.curToken,
+		Left:     left,
+		Optional: p.curTokenIs(token.QuestionDot),
+	}
+
+	if !p.expectPeek(token.Ident) {
+		return nil
+	}
+
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
 	exp.Arguments = p.parseExpressionList(token.RightParen)
@@ -290,7 +677,14 @@ func (p *Parser) parseExpressionList(ending token.TokenType) []ast.Expression {
 	}
 
 	p.nextToken()
-	args = append(args, p.parseExpression(PrecedenceLowest))
+
+	return p.parseRemainingExpressionList(p.parseExpression(PrecedenceLowest), ending)
+}
+
+// parseRemainingExpressionList parses the rest of a comma separated list once
+// its first expression has been read
+func (p *Parser) parseRemainingExpressionList(first ast.Expression, ending token.TokenType) []ast.Expression {
+	args := []ast.Expression{first}
 
 	for p.peekTokenIs(token.Comma) {
 		p.nextToken()
@@ -343,10 +737,12 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
-	case token.Let:
+	case token.Let, token.Const:
 		return p.parseLetStatement()
 	case token.Return:
 		return p.parseReturnStatement()
+	case token.Enum:
+		return p.parseEnumStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -386,8 +782,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.Ident, p.parseIdentifier)
 	p.registerPrefix(token.Int, p.parseIntegerLiteral)
+	p.registerPrefix(token.Float, p.parseFloatLiteral)
 	p.registerPrefix(token.Bang, p.parsePrefixExpression)
 	p.registerPrefix(token.Minus, p.parsePrefixExpression)
+	p.registerPrefix(token.Tilde, p.parsePrefixExpression)
 	p.registerPrefix(token.True, p.parseBoolean)
 	p.registerPrefix(token.False, p.parseBoolean)
 	p.registerPrefix(token.LeftParen, p.parseGroupedExpression)
@@ -395,6 +793,10 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(token.If, p.parseIfExpression)
 	p.registerPrefix(token.Function, p.parseFunctionLiteral)
 	p.registerPrefix(token.String, p.parseStringLiteral)
+	p.registerPrefix(token.Regex, p.parseRegexLiteral)
+	p.registerPrefix(token.Null, p.parseNullLiteral)
+	p.registerPrefix(token.SetBrace, p.parseSetLiteral)
+	p.registerPrefix(token.LeftBrace, p.parseHashLiteral)
 
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.Plus, p.parseInfixExpression)
@@ -406,8 +808,18 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.NotEq, p.parseInfixExpression)
 	p.registerInfix(token.Lt, p.parseInfixExpression)
 	p.registerInfix(token.Gt, p.parseInfixExpression)
+	p.registerInfix(token.Ampersand, p.parseInfixExpression)
+	p.registerInfix(token.VerticalBar, p.parseInfixExpression)
+	p.registerInfix(token.Caret, p.parseInfixExpression)
+	p.registerInfix(token.ShiftLeft, p.parseInfixExpression)
+	p.registerInfix(token.ShiftRight, p.parseInfixExpression)
 	p.registerInfix(token.LeftParen, p.parseCallExpression)
 	p.registerInfix(token.LeftBracket, p.parseIndexExpression)
+	p.registerInfix(token.Pipe, p.parsePipeExpression)
+	p.registerInfix(token.Coalesce, p.parseInfixExpression)
+	p.registerInfix(token.QuestionBracket, p.parseIndexExpression)
+	p.registerInfix(token.Dot, p.parseMemberExpression)
+	p.registerInfix(token.QuestionDot, p.parseMemberExpression)
 
 	p.nextToken()
 	p.nextToken()
@@ -416,7 +828,13 @@ func New(l *lexer.Lexer) *Parser {
 }
 
 func (p *Parser) parseIdentifier() ast.Expression {
-	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.Arrow) {
+		return p.parseArrowFunction([]*ast.Identifier{ident})
+	}
+
+	return ident
 }
 
 func (p *Parser) parseIntegerLiteral() ast.Expression {
@@ -433,11 +851,65 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	digits, ok := floatDigits(p.curToken.Literal)
+	value, err := strconv.ParseFloat(digits, 64)
+	if !ok || err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+// floatDigits removes the underscores separating digits in a float literal.
+// It reports false if an underscore is not between two digits
+func floatDigits(literal string) (string, bool) {
+	for i := 0; i < len(literal); i++ {
+		if literal[i] != '_' {
+			continue
+		}
+		if i == 0 || i == len(literal)-1 || !isDecimalDigit(literal[i-1]) || !isDecimalDigit(literal[i+1]) {
+			return "", false
+		}
+	}
+
+	return strings.ReplaceAll(literal, "_", ""), true
+}
+
+func isDecimalDigit(ch byte) bool {
+	return '0' <= ch && ch <= '9'
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	lit := &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 	return lit
 }
 
+func (p *Parser) parseRegexLiteral() ast.Expression {
+	literal := p.curToken.Literal
+	end := strings.LastIndex(literal, "/")
+	lit := &ast.RegexLiteral{Token: p.curToken, Pattern: literal[1:end], Flags: literal[end+1:]}
+
+	compiled, err := ast.CompileRegex(lit.Pattern, lit.Flags)
+	if err != nil {
+		msg := fmt.Sprintf("invalid regex %s: %v", literal, err)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Regexp = compiled
+	return lit
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{
 		Token: p.curToken,
@@ -472,16 +944,72 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parsePipeExpression desugars `left |> right` into a call of right with left
+// passed as its first argument, so `x |> f(y)` becomes `f(x, y)` and `x |> f`
+// becomes `f(x)`
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	right := p.parseExpression(precedence)
+	if right == nil {
+		return nil
+	}
+
+	if call, ok := right.(*ast.CallExpression); ok {
+		call.Arguments = append([]ast.Expression{left}, call.Arguments...)
+		return call
+	}
+
+	return &ast.CallExpression{Token: tok, Function: right, Arguments: []ast.Expression{left}}
+}
+
+// parseGroupedExpression parses everything starting with a `(`: arrow
+// functions, grouped expressions and tuples, which are told apart from a
+// grouped expression by a comma, e.g. `(a, b)` or `(a,)`
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	if p.peekArrowParameters() {
+		parameters := p.parseFunctionParameters()
+		if parameters == nil {
+			return nil
+		}
+		return p.parseArrowFunction(parameters)
+	}
+
+	tuple := &ast.TupleLiteral{Token: p.curToken, Elements: []ast.Expression{}}
+	if p.peekTokenIs(token.RightParen) {
+		p.nextToken()
+		return tuple
+	}
+
 	p.nextToken()
 
 	exp := p.parseExpression(PrecedenceLowest)
 
+	if !p.peekTokenIs(token.Comma) {
+		if !p.expectPeek(token.RightParen) {
+			return nil
+		} else {
+			return exp
+		}
+	}
+
+	tuple.Elements = append(tuple.Elements, exp)
+	for p.peekTokenIs(token.Comma) {
+		p.nextToken()
+		if p.peekTokenIs(token.RightParen) {
+			break
+		}
+		p.nextToken()
+		tuple.Elements = append(tuple.Elements, p.parseExpression(PrecedenceLowest))
+	}
+
 	if !p.expectPeek(token.RightParen) {
 		return nil
-	} else {
-		return exp
 	}
+
+	return tuple
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType, line int) {
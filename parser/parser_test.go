@@ -37,6 +37,64 @@ let foo = 83838;
 	}
 }
 
+func TestConstStatement(t *testing.T) {
+	input := `const x = 5;`
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	require.Truef(t, ok, "not *ast.LetStatement. got %T", program.Statements[0])
+
+	assert.True(t, stmt.Constant)
+	assert.Equal(t, "x", stmt.Name.Value)
+	assert.Equal(t, "const x = 5;", program.String())
+}
+
+func TestEnumStatement(t *testing.T) {
+	input := `enum Shape { Circle(r), Rect(w, h), Empty };`
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors())
+	require.Len(t, program.Statements, 1)
+
+	stmt, ok := program.Statements[0].(*ast.EnumStatement)
+	require.Truef(t, ok, "not *ast.EnumStatement. got %T", program.Statements[0])
+
+	assert.Equal(t, "Shape", stmt.Name.Value)
+	require.Len(t, stmt.Variants, 3)
+	assert.Equal(t, "Circle", stmt.Variants[0].Name.Value)
+	assert.Len(t, stmt.Variants[0].Fields, 1)
+	assert.Len(t, stmt.Variants[1].Fields, 2)
+	assert.Len(t, stmt.Variants[2].Fields, 0)
+	assert.Equal(t, "enum Shape { Circle(r), Rect(w, h), Empty }", program.String())
+}
+
+func TestInvalidEnumStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"enum Shape { Circle(r), Circle }", "duplicate variant Circle in enum Shape on line 1"},
+		{"enum Shape { Circle(r) Rect }", "expected ,, got IDENT instead on line 1"},
+		{"enum { Circle }", "expected IDENT, got { instead on line 1"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		_ = p.ParseProgram()
+
+		require.NotEmptyf(t, p.Errors(), "test case %d failed", idx)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "test case %d failed", idx)
+	}
+}
+
 func TestInvalidStatement(t *testing.T) {
 	input := `
 let x 5;
@@ -261,6 +319,14 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"a + b + c", "((a + b) + c)"},
 		{"a + b * c", "(a + (b * c))"},
 		{"a + b * c + d / e - f", "(((a + (b * c)) + (d / e)) - f)"},
+		{"a | b ^ c & d", "(a | (b ^ (c & d)))"},
+		{"a & b == c", "((a & b) == c)"},
+		{"flags & 4 == 4", "((flags & 4) == 4)"},
+		{"a | b != c ^ d", "((a | b) != (c ^ d))"},
+		{"a & b < c", "((a & b) < c)"},
+		{"a << 1 + b", "(a << (1 + b))"},
+		{"a << 1 < b >> 2", "((a << 1) < (b >> 2))"},
+		{"~a & b", "((~a) & b)"},
 	}
 
 	for idx, tt := range tests {
@@ -296,6 +362,55 @@ func TestOperatorPrecedenceParsingWithParens(t *testing.T) {
 	}
 }
 
+func TestPipeExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x |> f", "f(x)"},
+		{"x |> f(y)", "f(x, y)"},
+		{"data |> filter(isEven) |> map(double) |> sum", "sum(map(filter(data, isEven), double))"},
+		{"a + b |> f", "f((a + b))"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		actual := program.String()
+		assert.Equalf(t, tt.expected, actual, "test case %d failed", idx)
+	}
+}
+
+func TestNullSafetyParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a ?? b", "(a ?? b)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a == null ?? b", "((a == null) ?? b)"},
+		{"a?.b", "a?.b"},
+		{"a.b?.c", "a.b?.c"},
+		{"a?[1 + 2]", "[a]?[(1 + 2)]"},
+		{"x?.y ?? 5", "(x?.y ?? 5)"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		actual := program.String()
+		assert.Equalf(t, tt.expected, actual, "test case %d failed", idx)
+	}
+}
+
 func TestIfExpression(t *testing.T) {
 	input := `if (x < y) { x }`
 
@@ -372,6 +487,91 @@ func TestFunctionLiteralParsing(t *testing.T) {
 
 }
 
+func TestArrowFunctionParsing(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedParams []string
+		expectedBody   string
+	}{
+		{"(x, y) => x + y", []string{"x", "y"}, "(x + y)"},
+		{"x => x * 2", []string{"x"}, "(x * 2)"},
+		{"() => 5", []string{}, "5"},
+		{"x => y => x + y", []string{"x"}, "fn(y) (x + y)"},
+		{"(x: int) => x", []string{"x: int"}, "x"},
+		{"(f: fn(int, [string]) -> int, xs: [int]) => f(xs)", []string{"f: fn(int, [string]) -> int", "xs: [int]"}, "f(xs)"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+		require.Len(t, program.Statements, 1)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		require.Truef(t, ok, "statement is not an ast.ExpressionStatement. Got %T instead", program.Statements[0])
+
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		require.Truef(t, ok, "expression was not a FunctionLiteral. Got %T instead", stmt.Expression)
+
+		params := []string{}
+		for _, param := range function.Parameters {
+			params = append(params, param.String())
+		}
+
+		assert.Equalf(t, tt.expectedParams, params, "test case %d failed", idx)
+		assert.Equalf(t, tt.expectedBody, function.Body.String(), "test case %d failed", idx)
+	}
+}
+
+func TestGroupedExpressionIsNotArrowFunction(t *testing.T) {
+	input := "(x) * (y + z)"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+
+	require.Empty(t, p.errors)
+	assert.Equal(t, "(x * (y + z))", program.String())
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x: int = 5;", "let x: int = 5;"},
+		{"let xs: [[string]] = y;", "let xs: [[string]] = y;"},
+		{"fn(a: string, b: [int]) -> bool { true }", "fn(a: string, b: [int]) -> bool true"},
+		{"fn(f: fn(int, int) -> int, x) { f(x, x) }", "fn(f: fn(int, int) -> int, x) f(x, x)"},
+		{"fn() -> fn() -> any { x }", "fn() -> fn() -> any x"},
+		{"let xs: [float] = [1.5];", "let xs: [float] = [1.5];"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		actual := program.String()
+		assert.Equalf(t, tt.expected, actual, "test case %d failed", idx)
+	}
+}
+
+func TestInvalidTypeAnnotation(t *testing.T) {
+	input := `let x: integer = 5;`
+
+	l := lexer.New(input)
+	p := New(l)
+	_ = p.ParseProgram()
+
+	require.NotEmpty(t, p.errors)
+	assert.Equal(t, "unknown type integer on line 1", p.errors[0])
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -435,6 +635,92 @@ func TestEmptyArrayLiteral(t *testing.T) {
 	assert.Len(t, exp.Elements, 0)
 }
 
+func TestSetAndTupleLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"#{1, 2, 3}", "#{1, 2, 3}"},
+		{"#{}", "#{}"},
+		{"(1, 2)", "(1, 2)"},
+		{"(1 + 2, a * b, c)", "((1 + 2), (a * b), c)"},
+		{"(a,)", "(a,)"},
+		{"(1, 2,)", "(1, 2)"},
+		{"()", "()"},
+		{"(1)", "1"},
+		{"(a, b) => a", "fn(a, b) a"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		actual := program.String()
+		assert.Equalf(t, tt.expected, actual, "test case %d failed", idx)
+	}
+}
+
+func TestHashLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"one": 1, "two": 2}`, `{"one": 1, "two": 2}`},
+		{"{}", "{}"},
+		{"{a + b: c * 2,}", "{(a + b): (c * 2)}"},
+		{`{"b": 2, "a": [1]}["a"]`, `[{"b": 2, "a": [1]}]["a"]`},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		actual := program.String()
+		assert.Equalf(t, tt.expected, actual, "test case %d failed", idx)
+	}
+
+	l := lexer.New(`{"a" 1}`)
+	p := New(l)
+	_ = p.ParseProgram()
+
+	require.NotEmpty(t, p.errors)
+	assert.Equal(t, "expected :, got INT instead on line 1", p.errors[0])
+}
+
+func TestComprehensionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in xs if x > 0]", "[(x * 2) for x in xs if (x > 0)]"},
+		{"[x for x in [1, 2]]", "[x for x in [1, 2]]"},
+		{"#{x % 3 for x in xs}", "#{(x % 3) for x in xs}"},
+		{"[(x, y) for x in f(a, b) if g(x)]", "[(x, y) for x in f(a, b) if g(x)]"},
+		{"{x: x * x for x in xs}", "{x: (x * x) for x in xs}"},
+		{"{k % 2: v for v in xs if v > 1}", "{(k % 2): v for v in xs if (v > 1)}"},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		_, ok := stmt.Expression.(*ast.Comprehension)
+		require.Truef(t, ok, "expected Comprehension, got %T instead", stmt.Expression)
+
+		assert.Equalf(t, tt.expected, program.String(), "test case %d failed", idx)
+	}
+}
+
 func TestArrayIndexParsing(t *testing.T) {
 	input := `foo[2]`
 	l := lexer.New(input)
@@ -457,6 +743,97 @@ func TestArrayIndexParsing(t *testing.T) {
 	testIntegerLiteral(t, exp.Right, 2)
 }
 
+func TestIntegerLiteralBases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xff", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0xDEAD_BEEF", 0xDEADBEEF},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+		require.Len(t, program.Statements, 1)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		integer, ok := stmt.Expression.(*ast.IntegerLiteral)
+		require.Truef(t, ok, "expression not *ast.IntegerLiteral. got %T instead", stmt.Expression)
+
+		assert.Equalf(t, tt.expected, integer.Value, "test case %d failed", idx)
+	}
+
+	l := lexer.New("0b102")
+	p := New(l)
+	_ = p.ParseProgram()
+
+	require.Len(t, p.errors, 1)
+	assert.Equal(t, `could not parse "0b102" as integer`, p.errors[0])
+}
+
+func TestFloatLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"1.5", 1.5},
+		{"0.125", 0.125},
+		{"1_000.5", 1000.5},
+	}
+
+	for idx, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.Emptyf(t, p.errors, "test case %d failed", idx)
+		require.Len(t, program.Statements, 1)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		float, ok := stmt.Expression.(*ast.FloatLiteral)
+		require.Truef(t, ok, "expression not *ast.FloatLiteral. got %T instead", stmt.Expression)
+
+		assert.Equalf(t, tt.expected, float.Value, "test case %d failed", idx)
+	}
+
+	l := lexer.New("1.5_")
+	p := New(l)
+	_ = p.ParseProgram()
+
+	require.NotEmpty(t, p.errors)
+}
+
+func TestRegexLiteral(t *testing.T) {
+	l := lexer.New(`/(\w+)@example\.com/im`)
+	p := New(l)
+	program := p.ParseProgram()
+
+	require.Empty(t, p.errors)
+	require.Len(t, program.Statements, 1)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	regex, ok := stmt.Expression.(*ast.RegexLiteral)
+	require.Truef(t, ok, "expression not *ast.RegexLiteral. got %T instead", stmt.Expression)
+
+	assert.Equal(t, `(\w+)@example\.com`, regex.Pattern)
+	assert.Equal(t, "im", regex.Flags)
+	assert.True(t, regex.Regexp.MatchString("Bob@EXAMPLE.com"))
+
+	for _, input := range []string{"/a(/", "/a/x"} {
+		p := New(lexer.New(input))
+		_ = p.ParseProgram()
+
+		assert.NotEmptyf(t, p.errors, "expected %s to fail to parse", input)
+	}
+}
+
 func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) {
 	integ, ok := il.(*ast.IntegerLiteral)
 	if !assert.Truef(t, ok, "il not *ast.IntegerLiteral. got %T instead", il) {
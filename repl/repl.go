@@ -12,9 +12,8 @@ import (
 
 const PromptString = "> "
 
-func Start(in io.Reader, out io.Writer) {
+func Start(in io.Reader, out io.Writer, env *object.Environment) {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
 
 	for {
 		fmt.Print(PromptString)
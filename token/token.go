@@ -7,7 +7,9 @@ const (
 	// identifiers & literals
 	Ident  = "IDENT"
 	Int    = "INT"
+	Float  = "FLOAT"
 	String = "STRING"
+	Regex  = "REGEX"
 
 	// Operators
 	Assign   = "="
@@ -24,9 +26,26 @@ const (
 	Lt = "<"
 	Gt = ">"
 
+	Ampersand   = "&"
+	VerticalBar = "|"
+	Caret       = "^"
+	Tilde       = "~"
+	ShiftLeft   = "<<"
+	ShiftRight  = ">>"
+
+	Coalesce  = "??"
+	Pipe      = "|>"
+	Arrow     = "=>"
+	ThinArrow = "->"
+
 	// Delimiters
 	Comma     = ","
 	Semicolon = ";"
+	Colon     = ":"
+	Dot       = "."
+
+	QuestionDot     = "?."
+	QuestionBracket = "?["
 
 	LeftParen    = "("
 	RightParen   = ")"
@@ -34,25 +53,36 @@ const (
 	RightBrace   = "}"
 	LeftBracket  = "["
 	RightBracket = "]"
+	SetBrace     = "#{"
 
 	// Keywords
 	Function = "FUNCTION"
 	Let      = "LET"
+	Const    = "CONST"
 	True     = "TRUE"
 	False    = "FALSE"
 	If       = "IF"
 	Else     = "ELSE"
 	Return   = "RETURN"
+	Null     = "NULL"
+	Enum     = "ENUM"
+	For      = "FOR"
+	In       = "IN"
 )
 
 var keywords = map[string]TokenType{
 	"fn":     Function,
 	"let":    Let,
+	"const":  Const,
 	"true":   True,
 	"false":  False,
 	"if":     If,
 	"else":   Else,
 	"return": Return,
+	"null":   Null,
+	"enum":   Enum,
+	"for":    For,
+	"in":     In,
 }
 
 func LookupIdent(ident string) TokenType {